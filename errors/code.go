@@ -0,0 +1,189 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap/zapcore"
+)
+
+//go:generate gen-enum -type=ErrorCode
+
+// ErrorCode classifies an error so callers can decide how to react to it, e.g.
+// which HTTP status to respond with or whether to retry. The codes are the
+// ones of gRPC.
+type ErrorCode int
+
+const (
+	OK                 ErrorCode = iota // name=ok
+	Canceled                            // name=canceled
+	Unknown                             // name=unknown
+	InvalidArgument                     // name=invalid_argument
+	DeadlineExceeded                    // name=deadline_exceeded
+	NotFound                            // name=not_found
+	AlreadyExists                       // name=already_exists
+	PermissionDenied                    // name=permission_denied
+	ResourceExhausted                   // name=resource_exhausted
+	FailedPrecondition                  // name=failed_precondition
+	Aborted                             // name=aborted
+	OutOfRange                          // name=out_of_range
+	Unimplemented                       // name=unimplemented
+	Internal                            // name=internal
+	Unavailable                         // name=unavailable
+	DataLoss                            // name=data_loss
+	Unauthenticated                     // name=unauthenticated
+)
+
+var httpStatuses = map[ErrorCode]int{
+	OK:                 http.StatusOK,
+	Canceled:           499, // Client Closed Request
+	Unknown:            http.StatusInternalServerError,
+	InvalidArgument:    http.StatusBadRequest,
+	DeadlineExceeded:   http.StatusGatewayTimeout,
+	NotFound:           http.StatusNotFound,
+	AlreadyExists:      http.StatusConflict,
+	PermissionDenied:   http.StatusForbidden,
+	ResourceExhausted:  http.StatusTooManyRequests,
+	FailedPrecondition: http.StatusBadRequest,
+	Aborted:            http.StatusConflict,
+	OutOfRange:         http.StatusBadRequest,
+	Unimplemented:      http.StatusNotImplemented,
+	Internal:           http.StatusInternalServerError,
+	Unavailable:        http.StatusServiceUnavailable,
+	DataLoss:           http.StatusInternalServerError,
+	Unauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatus returns the HTTP status code that corresponds to c.
+func (c ErrorCode) HTTPStatus() int {
+	if status, ok := httpStatuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+type withCode struct {
+	err  error
+	code ErrorCode
+}
+
+// WithCode classifies err with code. It returns nil if err is nil.
+func WithCode(err error, code ErrorCode) error {
+	if err == nil {
+		return nil
+	}
+
+	return &withCode{
+		err:  err,
+		code: code,
+	}
+}
+
+// Code returns the code of err. The code closest to the top of the chain
+// wins, so errors can be reclassified as they're wrapped. Errors from the
+// context package are classified as Canceled and DeadlineExceeded, and a
+// group of errors has a code if all of its errors have that same code.
+//
+// Code returns OK for nil errors and Unknown for errors that weren't
+// classified.
+func Code(err error) ErrorCode {
+	if err == nil {
+		return OK
+	}
+
+	for e := err; e != nil; e = Unwrap(e) {
+		switch e := e.(type) {
+		case *withCode:
+			return e.code
+		case interface{ ErrorCode() ErrorCode }:
+			return e.ErrorCode()
+		case interface{ Unwrap() []error }:
+			return groupCode(e.Unwrap())
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return DeadlineExceeded
+	}
+
+	return Unknown
+}
+
+func groupCode(errs []error) ErrorCode {
+	if len(errs) == 0 {
+		return Unknown
+	}
+
+	code := Code(errs[0])
+	for _, err := range errs[1:] {
+		if Code(err) != code {
+			return Unknown
+		}
+	}
+	return code
+}
+
+// IsRetryable reports whether the operation that failed with err may succeed
+// if it's tried again.
+func IsRetryable(err error) bool {
+	switch Code(err) {
+	case Unavailable, DeadlineExceeded, ResourceExhausted, Aborted:
+		return true
+	}
+	return false
+}
+
+// IsNotFound reports whether err is classified as NotFound.
+func IsNotFound(err error) bool {
+	return Code(err) == NotFound
+}
+
+// IsInvalidArgument reports whether err is classified as InvalidArgument.
+func IsInvalidArgument(err error) bool {
+	return Code(err) == InvalidArgument
+}
+
+// Error returns the message of the wrapped error.
+func (w *withCode) Error() string { return w.err.Error() }
+
+// Is implements the inline interface (`err.(interface{ Is(error) bool })`) that
+// the standard libary errors.Is looks for in errors.
+func (w *withCode) Is(target error) bool { return errors.Is(w.err, target) }
+
+// As implements the inline interface (`err.(interface{ As(any) bool })`) that
+// the standard libary errors.As looks for in errors.
+func (w *withCode) As(target interface{}) bool { return errors.As(w.err, target) }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withCode) Unwrap() error { return w.err }
+
+// Format provides a method for fmt.Sprint(f) or Fprint(f) to generate output
+// with. %+v prints the code after the wrapped error.
+func (w *withCode) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('-') {
+			formatUser(f, w)
+			return
+		}
+		if f.Flag('+') {
+			fmt.Fprintf(f, "%+v\ncode=%s", w.err, w.code)
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		io.WriteString(f, w.Error())
+	}
+}
+
+// MarshalLogObject writes the message, code and fields of the error, so
+// zap.Any("error", err) logs them as an object.
+func (w *withCode) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return marshalLogObject(w, enc)
+}
@@ -8,8 +8,9 @@ import (
 )
 
 type withMessage struct {
-	err error
-	msg string
+	err   error
+	msg   string
+	stack *stack
 }
 
 // Error returns an error string with the message and cause concatenated.
@@ -31,9 +32,14 @@ func (w *withMessage) Unwrap() error { return w.err }
 func (w *withMessage) Format(f fmt.State, verb rune) {
 	switch verb {
 	case 'v':
+		if f.Flag('-') {
+			formatUser(f, w)
+			return
+		}
 		if f.Flag('+') {
 			fmt.Fprintf(f, "%+v\n", w.err)
 			io.WriteString(f, w.msg)
+			w.stack.Format(f, verb)
 			return
 		}
 		fallthrough
@@ -42,21 +48,59 @@ func (w *withMessage) Format(f fmt.State, verb rune) {
 	}
 }
 
+func (w *withMessage) stackTrace() Stack { return w.stack.frames() }
+
+// fundamental is an error with a message and the stack it was created at.
+type fundamental struct {
+	msg   string
+	stack *stack
+}
+
+func (f *fundamental) Error() string { return f.msg }
+
+// Format provides a method for fmt.Sprint(f) or Fprint(f) to generate output
+// with. %+v prints the stack the error was created at.
+func (f *fundamental) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('-') {
+			formatUser(s, f)
+			return
+		}
+		if s.Flag('+') {
+			io.WriteString(s, f.msg)
+			f.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, f.msg)
+	case 'q':
+		fmt.Fprintf(s, "%q", f.msg)
+	}
+}
+
+func (f *fundamental) stackTrace() Stack { return f.stack.frames() }
+
 // Wrap wraps an error and a corresponding message. It helps with discovering
-// where an error first occurred and the chain of events it caused.
+// where an error first occurred and the chain of events it caused. The stack
+// of the caller is recorded along with the message.
 func Wrap(err error, msg string) error {
 	return &withMessage{
-		err: err,
-		msg: msg,
+		err:   err,
+		msg:   msg,
+		stack: callers(),
 	}
 }
 
 // Wrapf wraps an error and a corresponding message. It helps with discovering
-// where an error first occurred and the chain of events it caused.
+// where an error first occurred and the chain of events it caused. The stack
+// of the caller is recorded along with the message.
 func Wrapf(err error, format string, args ...interface{}) error {
 	return &withMessage{
-		err: err,
-		msg: fmt.Sprintf(format, args...),
+		err:   err,
+		msg:   fmt.Sprintf(format, args...),
+		stack: callers(),
 	}
 }
 
@@ -66,9 +110,14 @@ func Unwrap(err error) error {
 	return errors.Unwrap(err)
 }
 
-// New wraps the standard library's New function.
+// New returns an error with text as its message, recording the stack of the
+// caller. Like the standard library's errors.New, every call returns a
+// distinct error.
 func New(text string) error {
-	return errors.New(text)
+	return &fundamental{
+		msg:   text,
+		stack: callers(),
+	}
 }
 
 // As wraps the standard library's As function to avoid name collisions.
@@ -82,6 +131,10 @@ func As(err error, target interface{}) bool {
 // Target does not need to implement the error type. Some packages will just
 // return an `fmt.Errorf` or `errors.New` and that makes it impossible to check
 // normally.
+//
+// Comparing messages can match errors from unrelated packages that happen to
+// have the same text. Use IsStrict, MatchesChain, MatchesMessage or
+// HasMessagePrefix to choose how errors are matched explicitly.
 func Is(err error, target interface{}) bool {
 	if target == nil {
 		return err == target
@@ -122,6 +175,14 @@ func Is(err error, target interface{}) bool {
 		if strings.TrimSpace(err.Error()) == strings.TrimSpace(s) {
 			return true
 		}
+
+		// The errors of a group are each compared on their own since
+		// the message of the group combines all of them.
+		for _, member := range members(err) {
+			if Is(member, s) {
+				return true
+			}
+		}
 	}
 
 	return false
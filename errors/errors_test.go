@@ -0,0 +1,459 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var errSentinel = New("sentinel")
+
+func origin() error {
+	return New("origin")
+}
+
+func TestStackTrace(t *testing.T) {
+	err := Wrap(Wrapf(origin(), "loading %s", "config"), "starting")
+
+	st := StackTrace(err)
+	require.NotEmpty(t, st)
+
+	// The deepest stack is the one recorded where the error was created.
+	assert.Equal(t, "github.com/rdeusser/x/errors.origin", st[0].Function)
+	assert.Equal(t, "errors_test.go", fmt.Sprintf("%s", st[0]))
+	assert.Equal(t, "origin", fmt.Sprintf("%n", st[0]))
+
+	assert.Nil(t, StackTrace(io.EOF))
+	assert.NotEmpty(t, StackTrace(Wrap(io.EOF, "reading")))
+}
+
+func TestStackDepth(t *testing.T) {
+	defer SetStackDepth(32)
+
+	SetStackDepth(1)
+	assert.Len(t, StackTrace(New("shallow")), 1)
+
+	SetStackDepth(0)
+	assert.Nil(t, StackTrace(New("none")))
+	assert.Equal(t, "none", fmt.Sprintf("%+v", New("none")))
+}
+
+func TestFormat(t *testing.T) {
+	err := Wrap(origin(), "starting")
+
+	assert.Equal(t, "starting: origin", fmt.Sprintf("%v", err))
+	assert.Equal(t, "starting: origin", fmt.Sprintf("%s", err))
+	assert.Equal(t, `"origin"`, fmt.Sprintf("%q", origin()))
+
+	want := `^origin
+github.com/rdeusser/x/errors.origin
+	.+/errors/errors_test.go:\d+
+(?s:.*)
+starting
+github.com/rdeusser/x/errors.TestFormat
+	.+/errors/errors_test.go:\d+
+`
+	assert.Regexp(t, regexp.MustCompile(want), fmt.Sprintf("%+v", err))
+}
+
+func TestIs(t *testing.T) {
+	err := Wrapf(errSentinel, "doing %d things", 2)
+
+	assert.True(t, Is(err, errSentinel))
+	assert.True(t, Is(err, "doing 2 things: sentinel"))
+	assert.False(t, Is(New("other"), errSentinel))
+}
+
+func TestFields(t *testing.T) {
+	err := With(origin(), "tenant", "acme", "attempt", 1)
+	err = Wrap(err, "loading")
+	err = With(err, "attempt", 2, "path", "/etc/app.yaml", 42)
+
+	assert.Equal(t, "loading: origin", err.Error())
+	assert.Equal(t, map[string]interface{}{
+		"tenant":  "acme",
+		"attempt": 2,
+		"path":    "/etc/app.yaml",
+		"!BADKEY": 42,
+	}, Fields(err))
+
+	assert.Nil(t, Fields(origin()))
+	assert.Nil(t, With(nil, "k", "v"))
+	assert.True(t, Is(err, "loading: origin"))
+	assert.NotEmpty(t, StackTrace(err))
+
+	assert.Contains(t, fmt.Sprintf("%+v", With(origin(), "k", "v")), "\nk=v")
+}
+
+func TestFieldsMarshalLogObject(t *testing.T) {
+	err := Wrap(With(origin(), "tenant", "acme", "attempt", 2), "loading")
+
+	enc := zapcore.NewMapObjectEncoder()
+	zap.Any("error", err).AddTo(enc)
+
+	assert.Equal(t, map[string]interface{}{
+		"message": "loading: origin",
+		"tenant":  "acme",
+		"attempt": int64(2),
+	}, enc.Fields["error"])
+}
+
+type codeError struct {
+	code int
+}
+
+func (e *codeError) Error() string { return "code " + fmt.Sprint(e.code) }
+
+func TestJoin(t *testing.T) {
+	assert.Nil(t, Join())
+	assert.Nil(t, Join(nil, nil))
+	assert.Nil(t, Append(nil))
+
+	plain := fmt.Errorf("plain failure")
+	err := Join(errSentinel, nil, Wrap(&codeError{code: 7}, "calling"), plain)
+
+	assert.Equal(t, "sentinel; calling: code 7; plain failure", err.Error())
+
+	assert.True(t, Is(err, errSentinel))
+	assert.True(t, Is(err, plain))
+	assert.True(t, Is(err, "plain failure"))
+	assert.True(t, Is(err, "calling: code 7"))
+	assert.True(t, Is(Wrap(err, "running"), "plain failure"))
+	assert.False(t, Is(err, "code 7"))
+
+	var ce *codeError
+	assert.True(t, As(Wrap(err, "running"), &ce))
+	assert.Equal(t, 7, ce.code)
+
+	err = Append(err, io.EOF)
+	assert.Len(t, err.(*MultiError).Errors(), 4)
+	assert.True(t, Is(err, io.EOF))
+
+	// A nil *MultiError is a nil error, wherever it's passed.
+	var group *MultiError
+	assert.Nil(t, Append(group))
+	assert.Nil(t, Append(nil, group))
+	assert.Nil(t, Join(group))
+
+	err = Append(group, io.EOF)
+	assert.Equal(t, []error{io.EOF}, err.(*MultiError).Errors())
+
+	err = Append(err, group, io.ErrUnexpectedEOF)
+	assert.Equal(t, []error{io.EOF, io.ErrUnexpectedEOF}, err.(*MultiError).Errors())
+}
+
+func TestMultiErrorFormat(t *testing.T) {
+	err := Join(origin(), io.EOF)
+
+	want := `^2 errors occurred:
+	\* origin
+	  github.com/rdeusser/x/errors.origin
+	  	.+/errors/errors_test.go:\d+
+(?s:.*)
+	\* EOF$`
+	assert.Regexp(t, regexp.MustCompile(want), fmt.Sprintf("%+v", err))
+	assert.Equal(t, "origin; EOF", fmt.Sprintf("%v", err))
+}
+
+func TestMultiErrorConcurrentAppend(t *testing.T) {
+	var errs MultiError
+	assert.Nil(t, errs.ErrorOrNil())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs.Append(&codeError{code: i})
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, errs.Len())
+	assert.Error(t, errs.ErrorOrNil())
+}
+
+func TestCode(t *testing.T) {
+	notFound := WithCode(New("no such user"), NotFound)
+
+	testcases := []struct {
+		name      string
+		err       error
+		code      ErrorCode
+		retryable bool
+	}{
+		{name: "nil", err: nil, code: OK},
+		{name: "unclassified", err: New("boom"), code: Unknown},
+		{name: "direct", err: notFound, code: NotFound},
+		{name: "wrapped", err: Wrap(With(notFound, "id", 1), "loading user"), code: NotFound},
+		{name: "reclassified", err: WithCode(Wrap(notFound, "loading user"), Internal), code: Internal},
+		{name: "retryable", err: Wrap(WithCode(io.EOF, Unavailable), "dialing"), code: Unavailable, retryable: true},
+		{name: "context", err: Wrap(context.DeadlineExceeded, "waiting"), code: DeadlineExceeded, retryable: true},
+		{name: "same group", err: Join(notFound, WithCode(io.EOF, NotFound)), code: NotFound},
+		{name: "mixed group", err: Join(notFound, io.EOF), code: Unknown},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.code, Code(tc.err))
+			assert.Equal(t, tc.retryable, IsRetryable(tc.err))
+		})
+	}
+
+	assert.True(t, IsNotFound(Wrap(notFound, "loading user")))
+	assert.False(t, IsInvalidArgument(notFound))
+	assert.Nil(t, WithCode(nil, NotFound))
+	assert.Equal(t, "no such user", notFound.Error())
+	assert.Equal(t, 404, NotFound.HTTPStatus())
+	assert.Equal(t, "not_found", NotFound.String())
+	assert.Equal(t, NotFound, StringToErrorCode("not_found"))
+
+	enc := zapcore.NewMapObjectEncoder()
+	zap.Any("error", Wrap(notFound, "loading user")).AddTo(enc)
+	assert.Equal(t, "not_found", enc.Fields["error"].(map[string]interface{})["code"])
+}
+
+func TestMatching(t *testing.T) {
+	refused := fmt.Errorf("connection refused")
+	err := Wrap(With(refused, "host", "db"), "dialing")
+
+	assert.True(t, IsStrict(err, refused))
+	assert.False(t, IsStrict(err, fmt.Errorf("connection refused")))
+	assert.False(t, IsStrict(Wrap(io.EOF, "reading"), New("EOF")))
+
+	assert.True(t, MatchesChain(err, "connection refused"))
+	assert.True(t, MatchesChain(err, "dialing"))
+	assert.True(t, MatchesChain(err, "dialing: connection refused"))
+	assert.False(t, MatchesChain(err, "connection"))
+	assert.False(t, Is(err, "connection refused"))
+
+	assert.True(t, MatchesMessage(err, regexp.MustCompile(`^connection \w+$`)))
+	assert.False(t, MatchesMessage(err, regexp.MustCompile(`timeout`)))
+
+	assert.True(t, HasMessagePrefix(err, "connection"))
+	assert.False(t, HasMessagePrefix(err, "refused"))
+
+	group := Wrap(Join(io.EOF, err), "syncing")
+	assert.True(t, MatchesChain(group, "connection refused"))
+	assert.True(t, HasMessagePrefix(group, "EOF"))
+	assert.False(t, MatchesChain(nil, ""))
+}
+
+func TestRegister(t *testing.T) {
+	Register("errors.errSentinel", errSentinel)
+	Register("errors.errSentinel", errSentinel)
+
+	assert.Equal(t, errSentinel, Registered("errors.errSentinel"))
+	assert.Nil(t, Registered("errors.errMissing"))
+	assert.Contains(t, RegisteredNames(), "errors.errSentinel")
+
+	assert.Panics(t, func() { Register("errors.errSentinel", New("sentinel")) })
+	assert.Panics(t, func() { Register("errors.errNil", nil) })
+}
+
+func TestJSON(t *testing.T) {
+	Register("errors.errSentinel", errSentinel)
+
+	cause := With(WithCode(errSentinel, NotFound), "tenant", "acme", "attempt", 2)
+	err := Wrap(fmt.Errorf("loading user: %w", cause), "handling request")
+
+	data, marshalErr := json.Marshal(err)
+	require.NoError(t, marshalErr)
+
+	decoded, decodeErr := UnmarshalJSON(data)
+	require.NoError(t, decodeErr)
+
+	assert.Equal(t, err.Error(), decoded.Error())
+	assert.True(t, IsStrict(decoded, errSentinel))
+	assert.False(t, IsStrict(decoded, io.EOF))
+	assert.Equal(t, NotFound, Code(decoded))
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "attempt": float64(2)}, Fields(decoded))
+	assert.Equal(t, StackTrace(err), StackTrace(decoded))
+	assert.True(t, MatchesChain(decoded, "loading user"))
+
+	// Decoded errors encode to the same document again.
+	again, marshalErr := MarshalJSON(decoded)
+	require.NoError(t, marshalErr)
+	assert.JSONEq(t, string(data), string(again))
+}
+
+type sentinelError struct{}
+
+func (sentinelError) Error() string { return "typed sentinel" }
+
+func (sentinelError) Is(target error) bool { return target == errSentinel }
+
+func TestJSONEquivalentSentinel(t *testing.T) {
+	Register("errors.errSentinel", errSentinel)
+
+	data, err := MarshalJSON(Wrap(sentinelError{}, "loading"))
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, "loading: typed sentinel", decoded.Error())
+	assert.True(t, IsStrict(decoded, errSentinel))
+}
+
+func TestJSONGroups(t *testing.T) {
+	Register("errors.errSentinel", errSentinel)
+
+	err := Wrap(Join(errSentinel, fmt.Errorf("plain failure")), "syncing")
+
+	data, marshalErr := MarshalJSON(err)
+	require.NoError(t, marshalErr)
+
+	decoded, decodeErr := UnmarshalJSON(data)
+	require.NoError(t, decodeErr)
+
+	assert.Equal(t, "syncing: sentinel; plain failure", decoded.Error())
+	assert.True(t, IsStrict(decoded, errSentinel))
+	assert.Len(t, members(decoded), 2)
+
+	// Groups of other packages keep their own message.
+	data, marshalErr = MarshalJSON(errors.Join(io.EOF, io.ErrUnexpectedEOF))
+	require.NoError(t, marshalErr)
+
+	decoded, decodeErr = UnmarshalJSON(data)
+	require.NoError(t, decodeErr)
+	assert.Equal(t, "EOF\nunexpected EOF", decoded.Error())
+}
+
+func TestJSONNil(t *testing.T) {
+	data, err := MarshalJSON(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+
+	decoded, err := UnmarshalJSON(data)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = UnmarshalJSON([]byte(`{"message":"boom"}`))
+	assert.Error(t, err)
+}
+
+func panics(v interface{}) {
+	panic(v)
+}
+
+func recovered(v interface{}) (err error) {
+	defer Recover(&err)
+	panics(v)
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	err := recovered("boom")
+	require.Error(t, err)
+
+	var p *PanicError
+	require.True(t, As(err, &p))
+	assert.Equal(t, "boom", p.Value)
+	assert.Equal(t, "panic: boom", err.Error())
+	assert.Equal(t, Internal, Code(err))
+
+	// The stack starts where the panic happened.
+	st := StackTrace(err)
+	require.NotEmpty(t, st)
+	assert.Equal(t, "github.com/rdeusser/x/errors.panics", st[0].Function)
+	assert.Contains(t, fmt.Sprintf("%+v", err), "errors.recovered")
+
+	err = recovered(Wrap(errSentinel, "loading"))
+	assert.True(t, IsStrict(err, errSentinel))
+	assert.Equal(t, "panic: loading: sentinel", err.Error())
+
+	var nilMap map[string]int
+	err = func() (err error) {
+		defer Recover(&err)
+		nilMap["key"] = 1
+		return nil
+	}()
+	assert.Contains(t, err.Error(), "assignment to entry in nil map")
+	assert.Contains(t, StackTrace(err)[0].Function, "TestRecover")
+
+	err = func() (err error) {
+		defer Recover(&err)
+		err = io.EOF
+		panics("after")
+		return nil
+	}()
+	assert.True(t, IsStrict(err, io.EOF))
+	assert.Equal(t, "EOF; panic: after", err.Error())
+
+	assert.NoError(t, func() (err error) {
+		defer Recover(&err)
+		return nil
+	}())
+}
+
+func TestFromPanic(t *testing.T) {
+	assert.Nil(t, FromPanic(nil))
+
+	var err error
+	func() {
+		defer func() {
+			err = FromPanic(recover())
+		}()
+		panics(io.EOF)
+	}()
+
+	assert.True(t, IsStrict(err, io.EOF))
+	assert.Equal(t, "github.com/rdeusser/x/errors.panics", StackTrace(err)[0].Function)
+
+	data, marshalErr := MarshalJSON(err)
+	require.NoError(t, marshalErr)
+	decoded, decodeErr := UnmarshalJSON(data)
+	require.NoError(t, decodeErr)
+	assert.Equal(t, "panic: EOF", decoded.Error())
+	assert.Equal(t, Internal, Code(decoded))
+	assert.Equal(t, StackTrace(err), StackTrace(decoded))
+}
+
+func TestHints(t *testing.T) {
+	err := WithUserMessage(Wrap(origin(), "reading /etc/app/config.yaml"), "The configuration couldn't be loaded.")
+	err = WithHint(err, "run `app init` to create it")
+	err = Wrap(WithHint(err, "check the permissions of /etc/app"), "starting")
+
+	assert.Equal(t, "starting: reading /etc/app/config.yaml: origin", err.Error())
+	assert.Equal(t, "The configuration couldn't be loaded.", UserMessage(err))
+	assert.Equal(t, []string{"check the permissions of /etc/app", "run `app init` to create it"}, Hints(err))
+
+	assert.Equal(t, "starting: reading /etc/app/config.yaml: origin", fmt.Sprintf("%v", err))
+	assert.Equal(t, `The configuration couldn't be loaded.
+hint: check the permissions of /etc/app
+hint: run `+"`app init`"+` to create it`, fmt.Sprintf("%-v", err))
+	assert.NotContains(t, fmt.Sprintf("%+v", err), "hint")
+
+	// Without a user message, the message of the error is shown.
+	assert.Equal(t, "EOF\nhint: retry", fmt.Sprintf("%-v", WithHint(io.EOF, "retry")))
+	assert.Equal(t, "EOF", UserMessage(io.EOF))
+	assert.Equal(t, "", UserMessage(nil))
+	assert.Nil(t, Hints(io.EOF))
+	assert.Nil(t, WithHint(nil, "retry"))
+	assert.Nil(t, WithUserMessage(nil, "oops"))
+
+	group := Join(WithHint(io.EOF, "retry"), WithHint(io.ErrUnexpectedEOF, "retry"))
+	assert.Equal(t, []string{"retry"}, Hints(group))
+
+	enc := zapcore.NewMapObjectEncoder()
+	zap.Any("error", err).AddTo(enc)
+	fields := enc.Fields["error"].(map[string]interface{})
+	assert.Equal(t, "The configuration couldn't be loaded.", fields["user_message"])
+	assert.Len(t, fields["hints"], 2)
+
+	data, marshalErr := MarshalJSON(err)
+	require.NoError(t, marshalErr)
+	decoded, decodeErr := UnmarshalJSON(data)
+	require.NoError(t, decodeErr)
+	assert.Equal(t, fmt.Sprintf("%-v", err), fmt.Sprintf("%-v", decoded))
+}
@@ -0,0 +1,262 @@
+// Package errtest asserts what error chains built with the errors package
+// contain: the messages added by Wrap and Wrapf, sentinels, codes, fields and
+// hints. When an assertion fails, the chain is printed as a tree, e.g.
+//
+//	starting: loading config: permission denied
+//	├─ starting
+//	├─ loading config
+//	├─ fields: path="/etc/app.yaml"
+//	└─ permission denied [fs.ErrPermission]
+package errtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rdeusser/x/errors"
+)
+
+// chain and layer mirror the JSON document errors.MarshalJSON encodes chains
+// to, which describes every layer of a chain.
+type chain struct {
+	Message string  `json:"message"`
+	Layers  []layer `json:"layers"`
+}
+
+type layer struct {
+	Message     string                     `json:"message"`
+	Code        string                     `json:"code"`
+	Fields      map[string]json.RawMessage `json:"fields"`
+	UserMessage string                     `json:"user_message"`
+	Hint        string                     `json:"hint"`
+	Sentinel    string                     `json:"sentinel"`
+	Errors      []chain                    `json:"errors"`
+}
+
+func describe(err error) (chain, error) {
+	var c chain
+
+	data, marshalErr := errors.MarshalJSON(err)
+	if marshalErr != nil {
+		return c, marshalErr
+	}
+
+	return c, json.Unmarshal(data, &c)
+}
+
+// Messages returns the messages added by each error in the chain of err, from
+// the outermost to the innermost, e.g. the message of every Wrap followed by
+// the one of the error that was wrapped. Errors that don't add a message, like
+// the ones of errors.With and errors.WithCode, are left out, as are the errors
+// of groups.
+func Messages(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	c, describeErr := describe(err)
+	if describeErr != nil {
+		return []string{err.Error()}
+	}
+
+	var msgs []string
+	for _, l := range c.Layers {
+		if l.Message != "" && len(l.Errors) == 0 {
+			msgs = append(msgs, l.Message)
+		}
+	}
+	return msgs
+}
+
+// Tree returns the chain of err as a tree with a line per error. The errors of
+// a group are branches of their own.
+func Tree(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+
+	c, describeErr := describe(err)
+	if describeErr != nil {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	b.WriteString(c.Message)
+	writeLayers(&b, c.Layers, "")
+	return b.String()
+}
+
+func writeLayers(b *strings.Builder, layers []layer, indent string) {
+	for i, l := range layers {
+		branch, next := "├─ ", "│  "
+		if i == len(layers)-1 {
+			branch, next = "└─ ", "   "
+		}
+
+		b.WriteString("\n" + indent + branch + l.label())
+
+		for j, member := range l.Errors {
+			memberBranch, memberNext := "├─ ", "│  "
+			if j == len(l.Errors)-1 {
+				memberBranch, memberNext = "└─ ", "   "
+			}
+
+			// A member that's a single error is written on one line.
+			if len(member.Layers) == 1 && len(member.Layers[0].Errors) == 0 {
+				b.WriteString("\n" + indent + next + memberBranch + member.Layers[0].label())
+				continue
+			}
+
+			b.WriteString("\n" + indent + next + memberBranch + member.Message)
+			writeLayers(b, member.Layers, indent+next+memberNext)
+		}
+	}
+}
+
+// label describes what the layer adds to the chain.
+func (l layer) label() string {
+	var parts []string
+
+	switch {
+	case len(l.Errors) == 1:
+		parts = append(parts, "group of 1 error")
+	case len(l.Errors) > 1:
+		parts = append(parts, fmt.Sprintf("group of %d errors", len(l.Errors)))
+	case l.Message != "":
+		parts = append(parts, l.Message)
+	}
+
+	if l.Code != "" {
+		parts = append(parts, "code: "+l.Code)
+	}
+
+	if len(l.Fields) > 0 {
+		keys := make([]string, 0, len(l.Fields))
+		for key := range l.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = key + "=" + string(l.Fields[key])
+		}
+		parts = append(parts, "fields: "+strings.Join(fields, " "))
+	}
+
+	if l.UserMessage != "" {
+		parts = append(parts, "user message: "+l.UserMessage)
+	}
+
+	if l.Hint != "" {
+		parts = append(parts, "hint: "+l.Hint)
+	}
+
+	label := strings.Join(parts, "; ")
+	if l.Sentinel != "" {
+		label += " [" + l.Sentinel + "]"
+	}
+	return strings.TrimSpace(label)
+}
+
+// AssertChain asserts that the messages added by the errors in the chain of
+// err are msgs, as returned by Messages.
+//
+//	err := errors.Wrap(errors.Wrapf(io.EOF, "reading %s", path), "loading config")
+//	errtest.AssertChain(t, err, "loading config", "reading /etc/app.yaml", "EOF")
+func AssertChain(t testing.TB, err error, msgs ...string) bool {
+	t.Helper()
+
+	got := Messages(err)
+	if assert.ObjectsAreEqual(msgs, got) || (len(msgs) == 0 && len(got) == 0) {
+		return true
+	}
+
+	return assert.Fail(t, "Error chain differs", "want: %q\ngot:  %q\n\n%s", msgs, got, Tree(err))
+}
+
+// AssertIs asserts that target is in the chain of err, using the semantics
+// of the standard library's errors.Is.
+func AssertIs(t testing.TB, err, target error) bool {
+	t.Helper()
+
+	if errors.IsStrict(err, target) {
+		return true
+	}
+
+	return assert.Fail(t, fmt.Sprintf("Error chain doesn't contain %q", target), Tree(err))
+}
+
+// AssertNotIs asserts that target isn't in the chain of err, using the
+// semantics of the standard library's errors.Is.
+func AssertNotIs(t testing.TB, err, target error) bool {
+	t.Helper()
+
+	if !errors.IsStrict(err, target) {
+		return true
+	}
+
+	return assert.Fail(t, fmt.Sprintf("Error chain contains %q", target), Tree(err))
+}
+
+// AssertCode asserts that errors.Code classifies err with code.
+func AssertCode(t testing.TB, err error, code errors.ErrorCode) bool {
+	t.Helper()
+
+	got := errors.Code(err)
+	if got == code {
+		return true
+	}
+
+	return assert.Fail(t, fmt.Sprintf("Error code is %s instead of %s", got, code), Tree(err))
+}
+
+// AssertFields asserts that fields are attached to err with errors.With.
+// Other fields may be attached too. Values are compared like assert.EqualValues
+// does, so e.g. an int matches an int64.
+func AssertFields(t testing.TB, err error, fields map[string]interface{}) bool {
+	t.Helper()
+
+	got := errors.Fields(err)
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var diffs []string
+	for _, key := range keys {
+		value, ok := got[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("%s: missing, want %#v", key, fields[key]))
+		case !assert.ObjectsAreEqualValues(fields[key], value):
+			diffs = append(diffs, fmt.Sprintf("%s: %#v, want %#v", key, value, fields[key]))
+		}
+	}
+
+	if len(diffs) == 0 {
+		return true
+	}
+
+	return assert.Fail(t, "Error fields differ", "%s\n\n%s", strings.Join(diffs, "\n"), Tree(err))
+}
+
+// AssertHint asserts that hint is attached to err with errors.WithHint.
+func AssertHint(t testing.TB, err error, hint string) bool {
+	t.Helper()
+
+	for _, h := range errors.Hints(err) {
+		if h == hint {
+			return true
+		}
+	}
+
+	return assert.Fail(t, fmt.Sprintf("Error doesn't have the hint %q", hint), Tree(err))
+}
@@ -0,0 +1,101 @@
+package errtest
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rdeusser/x/errors"
+)
+
+// recorder records the failures of assertions instead of failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestTree(t *testing.T) {
+	errors.Register("fs.ErrPermission", fs.ErrPermission)
+
+	err := errors.With(fs.ErrPermission, "path", "/etc/app.yaml")
+	err = errors.Wrap(errors.Wrap(err, "loading config"), "starting")
+
+	assert.Equal(t, `starting: loading config: permission denied
+├─ starting
+├─ loading config
+├─ fields: path="/etc/app.yaml"
+└─ permission denied [fs.ErrPermission]`, Tree(err))
+
+	group := errors.Wrap(errors.Join(
+		io.EOF,
+		errors.WithHint(errors.WithCode(errors.New("timeout"), errors.Unavailable), "retry"),
+	), "syncing")
+
+	assert.Equal(t, `syncing: EOF; timeout
+├─ syncing
+└─ group of 2 errors
+   ├─ EOF
+   └─ timeout
+      ├─ hint: retry
+      ├─ code: unavailable
+      └─ timeout`, Tree(group))
+
+	assert.Equal(t, "<nil>", Tree(nil))
+}
+
+func TestAssertions(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := errors.Wrapf(errors.With(errors.WithCode(sentinel, errors.NotFound), "id", 7), "loading user %d", 7)
+	err = errors.WithHint(fmt.Errorf("handling request: %w", err), "check the ID")
+
+	assert.Equal(t, []string{"handling request", "loading user 7", "sentinel"}, Messages(err))
+	assert.Nil(t, Messages(nil))
+
+	// Passing assertions.
+	r := &recorder{TB: t}
+	assert.True(t, AssertChain(r, err, "handling request", "loading user 7", "sentinel"))
+	assert.True(t, AssertChain(r, nil))
+	assert.True(t, AssertIs(r, err, sentinel))
+	assert.True(t, AssertNotIs(r, err, io.EOF))
+	assert.True(t, AssertCode(r, err, errors.NotFound))
+	assert.True(t, AssertFields(r, err, map[string]interface{}{"id": int64(7)}))
+	assert.True(t, AssertHint(r, err, "check the ID"))
+	assert.Empty(t, r.failures)
+
+	// Failing ones print the chain.
+	testcases := map[string]func(testing.TB) bool{
+		"chain":  func(t testing.TB) bool { return AssertChain(t, err, "loading user 7", "sentinel") },
+		"is":     func(t testing.TB) bool { return AssertIs(t, err, io.EOF) },
+		"not is": func(t testing.TB) bool { return AssertNotIs(t, err, sentinel) },
+		"code":   func(t testing.TB) bool { return AssertCode(t, err, errors.Internal) },
+		"fields": func(t testing.TB) bool {
+			return AssertFields(t, err, map[string]interface{}{"id": 8, "tenant": "acme"})
+		},
+		"hint": func(t testing.TB) bool { return AssertHint(t, err, "retry") },
+	}
+
+	for name, assertion := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			assert.False(t, assertion(r))
+			if assert.Len(t, r.failures, 1) {
+				assert.Contains(t, r.failures[0], "└─ sentinel")
+			}
+		})
+	}
+
+	r = &recorder{TB: t}
+	AssertFields(r, err, map[string]interface{}{"id": 8, "tenant": "acme"})
+	assert.True(t, strings.Contains(r.failures[0], "id: 7, want 8"), r.failures[0])
+	assert.True(t, strings.Contains(r.failures[0], `tenant: missing, want "acme"`), r.failures[0])
+}
@@ -0,0 +1,183 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// badKey is the key of a value passed to With without one.
+const badKey = "!BADKEY"
+
+// field is a key/value pair attached to an error.
+type field struct {
+	key   string
+	value interface{}
+}
+
+type withFields struct {
+	err    error
+	fields []field
+}
+
+// With attaches key/value pairs to err without changing its message, e.g.
+//
+//	errors.With(err, "tenant", tenant, "request_id", id)
+//
+// kv alternates between keys, which must be strings, and values. A value
+// without a key is added under "!BADKEY". With returns nil if err is nil.
+//
+// The zappretty encoder logs the fields of errors logged with zap.Error, and
+// its core those of errors added to loggers with With too. zap itself only
+// logs their message and %+v, so other encoders need the error logged with
+// zap.Any or zap.Object to log them.
+func With(err error, kv ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	fields := make([]field, 0, (len(kv)+1)/2)
+	for len(kv) > 0 {
+		key, ok := kv[0].(string)
+		if !ok || len(kv) == 1 {
+			fields = append(fields, field{key: badKey, value: kv[0]})
+			kv = kv[1:]
+			continue
+		}
+
+		fields = append(fields, field{key: key, value: kv[1]})
+		kv = kv[2:]
+	}
+
+	return &withFields{
+		err:    err,
+		fields: fields,
+	}
+}
+
+// Fields returns the key/value pairs attached to err and the errors it wraps.
+// When a key was attached more than once, the value closest to the top of the
+// chain wins.
+func Fields(err error) map[string]interface{} {
+	fields := fieldsOf(err)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.key] = f.value
+	}
+	return m
+}
+
+// fieldsOf returns the fields attached in the chain of err in the order they
+// were first attached, from the bottom of the chain up.
+func fieldsOf(err error) []field {
+	var layers [][]field
+	for ; err != nil; err = Unwrap(err) {
+		if w, ok := err.(*withFields); ok {
+			layers = append(layers, w.fields)
+		}
+	}
+
+	var (
+		fields []field
+		index  = map[string]int{}
+	)
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		for _, f := range layers[i] {
+			if j, ok := index[f.key]; ok {
+				fields[j].value = f.value
+				continue
+			}
+			index[f.key] = len(fields)
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// Error returns the message of the wrapped error.
+func (w *withFields) Error() string { return w.err.Error() }
+
+// Is implements the inline interface (`err.(interface{ Is(error) bool })`) that
+// the standard libary errors.Is looks for in errors.
+func (w *withFields) Is(target error) bool { return errors.Is(w.err, target) }
+
+// As implements the inline interface (`err.(interface{ As(any) bool })`) that
+// the standard libary errors.As looks for in errors.
+func (w *withFields) As(target interface{}) bool { return errors.As(w.err, target) }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withFields) Unwrap() error { return w.err }
+
+// Format provides a method for fmt.Sprint(f) or Fprint(f) to generate output
+// with. %+v prints the fields after the wrapped error.
+func (w *withFields) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('-') {
+			formatUser(f, w)
+			return
+		}
+		if f.Flag('+') {
+			fmt.Fprintf(f, "%+v\n", w.err)
+			for i, field := range w.fields {
+				if i > 0 {
+					io.WriteString(f, " ")
+				}
+				fmt.Fprintf(f, "%s=%v", field.key, field.value)
+			}
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		io.WriteString(f, w.Error())
+	}
+}
+
+// MarshalLogObject writes the message of the error and the fields attached in
+// its chain, so zap.Any("error", err) logs them as an object.
+func (w *withFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return marshalLogObject(w, enc)
+}
+
+// MarshalLogObject writes the message of the error and the fields attached in
+// its chain, so zap.Any("error", err) logs them as an object.
+func (w *withMessage) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return marshalLogObject(w, enc)
+}
+
+// MarshalLogObject writes the message of the error, so zap.Any("error", err)
+// logs it as an object like the other errors of this package.
+func (f *fundamental) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return marshalLogObject(f, enc)
+}
+
+func marshalLogObject(err error, enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", err.Error())
+
+	if code := Code(err); code != Unknown {
+		enc.AddString("code", code.String())
+	}
+
+	if msg := UserMessage(err); msg != err.Error() {
+		enc.AddString("user_message", msg)
+	}
+
+	if hints := Hints(err); len(hints) > 0 {
+		zap.Strings("hints", hints).AddTo(enc)
+	}
+
+	for _, f := range fieldsOf(err) {
+		zap.Any(f.key, f.value).AddTo(enc)
+	}
+
+	return nil
+}
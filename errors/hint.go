@@ -0,0 +1,148 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// withHint holds what's shown to users instead of the chain it wraps: a
+// message, a hint on how to fix the problem, or both.
+type withHint struct {
+	err  error
+	msg  string
+	hint string
+}
+
+// WithHint attaches a hint telling users how to fix the problem, e.g.
+//
+//	errors.WithHint(err, "run `app init` to create a config file")
+//
+// Hints aren't part of the message of err. They're returned by Hints and
+// printed by %-v. WithHint returns nil if err is nil.
+func WithHint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &withHint{
+		err:  err,
+		hint: hint,
+	}
+}
+
+// WithUserMessage attaches a message meant for users, which UserMessage and
+// %-v return instead of the message of err. That way CLIs and APIs don't show
+// internal details like paths while they're still logged. WithUserMessage
+// returns nil if err is nil.
+func WithUserMessage(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &withHint{
+		err: err,
+		msg: msg,
+	}
+}
+
+// UserMessage returns the message of err meant for users: the one closest to
+// the top of the chain attached with WithUserMessage, or the message of err
+// if there's none. It returns an empty string for nil errors.
+func UserMessage(err error) string {
+	for e := err; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withHint); ok && w.msg != "" {
+			return w.msg
+		}
+	}
+
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Hints returns the hints attached to err and the errors it wraps, from the
+// top of the chain down, followed by the hints of the errors of a group.
+// Hints attached more than once are only returned once.
+func Hints(err error) []string {
+	var (
+		hints []string
+		seen  = map[string]bool{}
+	)
+
+	var collect func(err error)
+	collect = func(err error) {
+		for ; err != nil; err = Unwrap(err) {
+			if w, ok := err.(*withHint); ok && w.hint != "" && !seen[w.hint] {
+				seen[w.hint] = true
+				hints = append(hints, w.hint)
+			}
+
+			if group, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, member := range group.Unwrap() {
+					collect(member)
+				}
+				return
+			}
+		}
+	}
+	collect(err)
+
+	return hints
+}
+
+// formatUser writes the view of err meant for users, which %-v prints: its
+// user message followed by its hints, one per line.
+func formatUser(f fmt.State, err error) {
+	io.WriteString(f, UserMessage(err))
+	for _, hint := range Hints(err) {
+		io.WriteString(f, "\nhint: ")
+		io.WriteString(f, hint)
+	}
+}
+
+// Error returns the message of the wrapped error.
+func (w *withHint) Error() string { return w.err.Error() }
+
+// Is implements the inline interface (`err.(interface{ Is(error) bool })`) that
+// the standard libary errors.Is looks for in errors.
+func (w *withHint) Is(target error) bool { return errors.Is(w.err, target) }
+
+// As implements the inline interface (`err.(interface{ As(any) bool })`) that
+// the standard libary errors.As looks for in errors.
+func (w *withHint) As(target interface{}) bool { return errors.As(w.err, target) }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withHint) Unwrap() error { return w.err }
+
+// Format provides a method for fmt.Sprint(f) or Fprint(f) to generate output
+// with. %-v prints the view meant for users, all other verbs the one of the
+// wrapped error, so hints don't end up in the details %+v prints.
+func (w *withHint) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('-') {
+			formatUser(f, w)
+			return
+		}
+		if f.Flag('+') {
+			fmt.Fprintf(f, "%+v", w.err)
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		io.WriteString(f, w.Error())
+	}
+}
+
+// MarshalLogObject writes the message, code, fields and hints of the error,
+// so zap.Any("error", err) logs them as an object.
+func (w *withHint) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return marshalLogObject(w, enc)
+}
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (w *withHint) MarshalJSON() ([]byte, error) { return MarshalJSON(w) }
@@ -0,0 +1,330 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// jsonError is the JSON document an error chain is encoded to. Message is the
+// message of the whole chain and is only there for readers of the document.
+type jsonError struct {
+	Message string      `json:"message"`
+	Layers  []jsonLayer `json:"layers"`
+}
+
+// jsonLayer is an error of a chain, from the outermost to the innermost.
+type jsonLayer struct {
+	// Message is the message the layer added. Unless Opaque is set, the
+	// message of the layer is Message followed by the message of the next
+	// layer, separated by a colon.
+	Message string `json:"message,omitempty"`
+	Opaque  bool   `json:"opaque,omitempty"`
+
+	Code        string                     `json:"code,omitempty"`
+	Fields      map[string]json.RawMessage `json:"fields,omitempty"`
+	UserMessage string                     `json:"user_message,omitempty"`
+	Hint        string                     `json:"hint,omitempty"`
+	Stack       Stack                      `json:"stack,omitempty"`
+	Sentinel    string                     `json:"sentinel,omitempty"`
+	Errors      []jsonError                `json:"errors,omitempty"`
+}
+
+// MarshalJSON encodes the chain of err as a JSON document describing each of
+// its errors: the message it added, its code, fields, hint and stack, and the
+// name of the sentinel it is if it was registered with Register. The errors
+// of groups are encoded as chains of their own.
+//
+// UnmarshalJSON rebuilds the chain. The errors of this package implement
+// json.Marshaler with MarshalJSON, so they can be embedded in other documents
+// as they are.
+func MarshalJSON(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(encodeChain(err))
+}
+
+// UnmarshalJSON rebuilds an error chain from a document written by
+// MarshalJSON. The rebuilt chain has the same messages, codes, fields and
+// stacks, and errors.Is matches it against the sentinels it contained that are
+// registered in this process too. Field values are decoded like
+// json.Unmarshal decodes into an interface{}, e.g. numbers become float64.
+//
+// decoded is the rebuilt error, which is nil for the document "null". err is
+// set instead if data isn't such a document. decoded isn't a concrete type so
+// that the nil it returns for "null" stays nil when it's used as an error.
+func UnmarshalJSON(data []byte) (decoded error, err error) {
+	var doc *jsonError
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	return doc.decode()
+}
+
+func encodeChain(err error) jsonError {
+	doc := jsonError{Message: err.Error()}
+
+	for err != nil {
+		next := Unwrap(err)
+
+		var layer jsonLayer
+
+		// The errors of this package match what they wrap, so only other
+		// errors are asked whether they're equivalent to a sentinel.
+		foreign := false
+
+		switch e := err.(type) {
+		case *withMessage:
+			layer.Message = e.msg
+			layer.Stack = e.stack.frames()
+		case *fundamental:
+			layer.Message = e.msg
+			layer.Stack = e.stack.frames()
+		case *withFields:
+			layer.Fields = encodeFields(e.fields)
+		case *withCode:
+			layer.Code = e.code.String()
+		case *withHint:
+			layer.UserMessage = e.msg
+			layer.Hint = e.hint
+		case *remoteError:
+			layer.Message = e.msg
+			layer.Opaque = e.opaque
+			layer.Stack = e.stack
+			layer.Sentinel = e.sentinelName
+		case interface{ Unwrap() []error }:
+			for _, member := range e.Unwrap() {
+				layer.Errors = append(layer.Errors, encodeChain(member))
+			}
+			if _, ok := err.(*MultiError); !ok {
+				layer.Message = err.Error()
+				layer.Opaque = true
+			}
+		default:
+			foreign = true
+
+			layer.Message = err.Error()
+			if next != nil {
+				if msg, ok := strings.CutSuffix(layer.Message, ": "+next.Error()); ok {
+					layer.Message = msg
+				} else {
+					layer.Opaque = true
+				}
+			}
+
+			if c, ok := err.(interface{ ErrorCode() ErrorCode }); ok {
+				layer.Code = c.ErrorCode().String()
+			}
+
+			if st, ok := err.(stackTracer); ok {
+				layer.Stack = st.stackTrace()
+			}
+		}
+
+		if layer.Sentinel == "" {
+			layer.Sentinel, _ = registeredName(err, foreign)
+		}
+
+		doc.Layers = append(doc.Layers, layer)
+		err = next
+	}
+
+	return doc
+}
+
+// encodeFields encodes the values of fields. Values that can't be encoded as
+// JSON are encoded as they're printed by fmt instead.
+func encodeFields(fields []field) map[string]json.RawMessage {
+	m := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(f.value))
+		}
+		m[f.key] = value
+	}
+	return m
+}
+
+// decode rebuilds the chain of doc.
+func (doc *jsonError) decode() (decoded error, err error) {
+	if len(doc.Layers) == 0 {
+		return nil, fmt.Errorf("errors: no layers in error %q", doc.Message)
+	}
+
+	for i := len(doc.Layers) - 1; i >= 0; i-- {
+		if decoded, err = doc.Layers[i].decode(decoded); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+// decode rebuilds the layer on top of next, the error of the layer below.
+func (l *jsonLayer) decode(next error) (decoded error, err error) {
+	decoded = next
+
+	if len(l.Errors) > 0 {
+		group := &MultiError{}
+		for i := range l.Errors {
+			member, err := l.Errors[i].decode()
+			if err != nil {
+				return nil, err
+			}
+			group.Append(member)
+		}
+		decoded = group
+	}
+
+	if l.Message != "" || l.Sentinel != "" || l.Stack != nil || decoded == nil {
+		decoded = &remoteError{
+			err:          decoded,
+			msg:          l.Message,
+			opaque:       l.Opaque,
+			stack:        l.Stack,
+			sentinel:     Registered(l.Sentinel),
+			sentinelName: l.Sentinel,
+		}
+	}
+
+	if len(l.Fields) > 0 {
+		fields, err := decodeFields(l.Fields)
+		if err != nil {
+			return nil, err
+		}
+		decoded = &withFields{
+			err:    decoded,
+			fields: fields,
+		}
+	}
+
+	if l.UserMessage != "" || l.Hint != "" {
+		decoded = &withHint{
+			err:  decoded,
+			msg:  l.UserMessage,
+			hint: l.Hint,
+		}
+	}
+
+	if l.Code != "" {
+		code := Unknown
+		if IsErrorCode(l.Code) {
+			code = StringToErrorCode(l.Code)
+		}
+		decoded = &withCode{
+			err:  decoded,
+			code: code,
+		}
+	}
+
+	return decoded, nil
+}
+
+func decodeFields(m map[string]json.RawMessage) ([]field, error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]field, len(keys))
+	for i, key := range keys {
+		fields[i].key = key
+		if err := json.Unmarshal(m[key], &fields[i].value); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// remoteError is an error rebuilt by UnmarshalJSON that isn't one of the
+// other errors of this package, e.g. a message added with Wrap or an error
+// created by another package. It matches the sentinel it was encoded as if
+// that's registered.
+type remoteError struct {
+	err          error
+	msg          string
+	opaque       bool
+	stack        Stack
+	sentinel     error
+	sentinelName string
+}
+
+// Error returns the message of the error as it was before it was encoded.
+func (r *remoteError) Error() string {
+	switch {
+	case r.err == nil || r.opaque:
+		return r.msg
+	case r.msg == "":
+		return r.err.Error()
+	}
+	return r.msg + ": " + r.err.Error()
+}
+
+// Is implements the inline interface (`err.(interface{ Is(error) bool })`) that
+// the standard libary errors.Is looks for in errors.
+func (r *remoteError) Is(target error) bool {
+	return r.sentinel != nil && target == r.sentinel
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (r *remoteError) Unwrap() error { return r.err }
+
+// Format provides a method for fmt.Sprint(f) or Fprint(f) to generate output
+// with. %+v prints the stack the error was encoded with.
+func (r *remoteError) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('-') {
+			formatUser(f, r)
+			return
+		}
+		if f.Flag('+') {
+			if r.err != nil {
+				fmt.Fprintf(f, "%+v\n", r.err)
+			}
+			io.WriteString(f, r.msg)
+			r.stack.Format(f, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(f, r.Error())
+	case 'q':
+		fmt.Fprintf(f, "%q", r.Error())
+	}
+}
+
+func (r *remoteError) stackTrace() Stack { return r.stack }
+
+// MarshalLogObject writes the message, code and fields of the error, so
+// zap.Any("error", err) logs them as an object.
+func (r *remoteError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return marshalLogObject(r, enc)
+}
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (w *withMessage) MarshalJSON() ([]byte, error) { return MarshalJSON(w) }
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (f *fundamental) MarshalJSON() ([]byte, error) { return MarshalJSON(f) }
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (w *withFields) MarshalJSON() ([]byte, error) { return MarshalJSON(w) }
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (w *withCode) MarshalJSON() ([]byte, error) { return MarshalJSON(w) }
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (m *MultiError) MarshalJSON() ([]byte, error) { return MarshalJSON(m) }
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (r *remoteError) MarshalJSON() ([]byte, error) { return MarshalJSON(r) }
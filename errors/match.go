@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// IsStrict reports whether any error in the chain of err matches target with
+// the semantics of the standard library's errors.Is: target has to be in the
+// chain or an error in it has to say it's equivalent with an Is method.
+// Messages are never compared.
+func IsStrict(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+// MatchesChain reports whether any error in the chain of err has msg as its
+// message, either in full or as the message a layer added with Wrap. Unlike
+// Is, it finds messages deep in the chain:
+//
+//	err := errors.Wrap(fmt.Errorf("connection refused"), "dialing")
+//	errors.MatchesChain(err, "connection refused") // true
+//	errors.MatchesChain(err, "dialing")            // true
+//	errors.Is(err, "connection refused")           // false
+func MatchesChain(err error, msg string) bool {
+	msg = strings.TrimSpace(msg)
+	return matchMessages(err, func(m string) bool {
+		return strings.TrimSpace(m) == msg
+	})
+}
+
+// MatchesMessage reports whether re matches the message of any error in the
+// chain of err.
+func MatchesMessage(err error, re *regexp.Regexp) bool {
+	return matchMessages(err, re.MatchString)
+}
+
+// HasMessagePrefix reports whether the message of any error in the chain of
+// err starts with prefix.
+func HasMessagePrefix(err error, prefix string) bool {
+	return matchMessages(err, func(m string) bool {
+		return strings.HasPrefix(m, prefix)
+	})
+}
+
+// matchMessages reports whether match returns true for the message of an
+// error in the chain of err, or the message a layer added to it. The errors
+// of groups are visited too.
+func matchMessages(err error, match func(string) bool) bool {
+	for ; err != nil; err = Unwrap(err) {
+		if match(err.Error()) {
+			return true
+		}
+
+		switch e := err.(type) {
+		case *withMessage:
+			if match(e.msg) {
+				return true
+			}
+		case *remoteError:
+			if e.msg != "" && match(e.msg) {
+				return true
+			}
+		}
+
+		if group, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, member := range group.Unwrap() {
+				if matchMessages(member, match) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,181 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// MultiError is a group of errors. Is and As, both the ones of this package
+// and of the standard library, match if any of the errors in the group does.
+//
+// The zero value is an empty group ready to use. Errors can be appended from
+// several goroutines at once:
+//
+//	var errs errors.MultiError
+//	for _, job := range jobs {
+//		wg.Add(1)
+//		go func(job Job) {
+//			defer wg.Done()
+//			errs.Append(job.Run())
+//		}(job)
+//	}
+//	wg.Wait()
+//	return errs.ErrorOrNil()
+//
+// A MultiError must not be copied after first use.
+type MultiError struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// Join returns an error grouping the non-nil errs, or nil if there are none.
+func Join(errs ...error) error {
+	m := &MultiError{}
+	m.Append(errs...)
+	return m.ErrorOrNil()
+}
+
+// Append returns an error grouping err and errs, ignoring nil errors. If err
+// is a MultiError, errs are added to a copy of its group rather than to a new
+// group containing it. It returns nil if all the errors are nil.
+//
+// A nil *MultiError counts as a nil error, so it can be used to accumulate
+// errors:
+//
+//	var errs *errors.MultiError
+//	err := errors.Append(errs, io.EOF)
+func Append(err error, errs ...error) error {
+	m := &MultiError{}
+	if group, ok := err.(*MultiError); ok && group != nil {
+		m.Append(group.Errors()...)
+	} else {
+		m.Append(err)
+	}
+	m.Append(errs...)
+	return m.ErrorOrNil()
+}
+
+// Append adds the non-nil errs to the group, leaving out nil *MultiErrors
+// too. It's safe to call from several goroutines at once.
+func (m *MultiError) Append(errs ...error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, err := range errs {
+		if group, ok := err.(*MultiError); err == nil || ok && group == nil {
+			continue
+		}
+		m.errs = append(m.errs, err)
+	}
+}
+
+// Errors returns the errors in the group.
+func (m *MultiError) Errors() []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]error(nil), m.errs...)
+}
+
+// Len returns the number of errors in the group.
+func (m *MultiError) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.errs)
+}
+
+// ErrorOrNil returns the group if it has errors and nil otherwise, so it can
+// be returned as an error without ending up with a non-nil empty group.
+func (m *MultiError) ErrorOrNil() error {
+	if m == nil || m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Error returns the messages of the errors in the group separated by
+// semicolons.
+func (m *MultiError) Error() string {
+	errs := m.Errors()
+
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors in the group, which is what the standard
+// library's errors.Is and errors.As look for since Go 1.20.
+func (m *MultiError) Unwrap() []error {
+	return m.Errors()
+}
+
+// Format provides a method for fmt.Sprint(f) or Fprint(f) to generate output
+// with. %+v lists the errors in the group with their details, one per line.
+func (m *MultiError) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('-') {
+			formatUser(f, m)
+			return
+		}
+		if f.Flag('+') {
+			errs := m.Errors()
+
+			if len(errs) == 1 {
+				io.WriteString(f, "1 error occurred:")
+			} else {
+				io.WriteString(f, strconv.Itoa(len(errs))+" errors occurred:")
+			}
+
+			for _, err := range errs {
+				detail := fmt.Sprintf("%+v", err)
+				io.WriteString(f, "\n\t* ")
+				io.WriteString(f, strings.ReplaceAll(detail, "\n", "\n\t  "))
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(f, m.Error())
+	case 'q':
+		fmt.Fprintf(f, "%q", m.Error())
+	}
+}
+
+// MarshalLogObject writes the message of the group and its errors, so
+// zap.Any("error", err) logs them as an object.
+func (m *MultiError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", m.Error())
+
+	return enc.AddArray("errors", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, err := range m.Errors() {
+			if obj, ok := err.(zapcore.ObjectMarshaler); ok {
+				if err := arr.AppendObject(obj); err != nil {
+					return err
+				}
+				continue
+			}
+			arr.AppendString(err.Error())
+		}
+		return nil
+	}))
+}
+
+// members returns the errors grouped by the first error in the chain of err
+// that groups errors, if any.
+func members(err error) []error {
+	for ; err != nil; err = Unwrap(err) {
+		if group, ok := err.(interface{ Unwrap() []error }); ok {
+			return group.Unwrap()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// PanicError is a recovered panic turned into an error by Recover or
+// FromPanic. Errors are classified as Internal.
+type PanicError struct {
+	// Value is the value the function panicked with.
+	Value interface{}
+
+	stack Stack
+}
+
+// Recover recovers from a panic and turns it into an error stored in errp. It
+// needs to be deferred itself, e.g.
+//
+//	func run() (err error) {
+//		defer errors.Recover(&err)
+//		...
+//	}
+//
+// If errp already holds an error, the panic is joined with it.
+func Recover(errp *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	var err error = fromPanic(v)
+	if *errp != nil {
+		err = Join(*errp, err)
+	}
+	*errp = err
+}
+
+// FromPanic turns v, the value returned by recover, into an error. The stack
+// of the panic is recorded, so FromPanic needs to be called by the deferred
+// function that called recover:
+//
+//	defer func() {
+//		if err := errors.FromPanic(recover()); err != nil {
+//			log.Error("handler panicked", zap.Error(err))
+//		}
+//	}()
+//
+// FromPanic returns nil if v is nil.
+func FromPanic(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	return fromPanic(v)
+}
+
+func fromPanic(v interface{}) *PanicError {
+	return &PanicError{
+		Value: v,
+		stack: panicStack(),
+	}
+}
+
+// panicStack records the stack of the function that panicked, leaving out the
+// frames of the runtime handling the panic and of recovering from it. If
+// there's no panic, it's the stack of the caller of FromPanic or Recover.
+func panicStack() Stack {
+	depth := int(stackDepth.Load())
+	if depth == 0 {
+		return nil
+	}
+
+	// Leave room for the frames that are cut off.
+	pcs := make([]uintptr, depth+16)
+	n := runtime.Callers(3, pcs)
+
+	var all Stack
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		all = append(all, Frame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+		if !more {
+			break
+		}
+	}
+
+	// The first frame is FromPanic or Recover.
+	start := 1
+	for i, frame := range all {
+		if frame.Function == "runtime.gopanic" {
+			start = i + 1
+			break
+		}
+	}
+
+	// E.g. runtime.sigpanic for nil pointer dereferences.
+	for start < len(all) && strings.HasPrefix(all[start].Function, "runtime.") {
+		start++
+	}
+
+	st := all[start:]
+	if len(st) > depth {
+		st = st[:depth]
+	}
+	return st
+}
+
+// Error returns the value the function panicked with.
+func (p *PanicError) Error() string { return fmt.Sprintf("panic: %v", p.Value) }
+
+// Unwrap returns the value the function panicked with if it's an error.
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// ErrorCode classifies panics as Internal.
+func (p *PanicError) ErrorCode() ErrorCode { return Internal }
+
+// Format provides a method for fmt.Sprint(f) or Fprint(f) to generate output
+// with. %+v prints the stack of the panic.
+func (p *PanicError) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('-') {
+			formatUser(f, p)
+			return
+		}
+		if f.Flag('+') {
+			io.WriteString(f, p.Error())
+			p.stack.Format(f, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(f, p.Error())
+	case 'q':
+		fmt.Fprintf(f, "%q", p.Error())
+	}
+}
+
+func (p *PanicError) stackTrace() Stack { return p.stack }
+
+// MarshalLogObject writes the message, code and fields of the error, so
+// zap.Any("error", err) logs them as an object.
+func (p *PanicError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return marshalLogObject(p, enc)
+}
+
+// MarshalJSON implements json.Marshaler with the package-level MarshalJSON.
+func (p *PanicError) MarshalJSON() ([]byte, error) { return MarshalJSON(p) }
@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var registry = struct {
+	sync.RWMutex
+	byName map[string]error
+	byErr  map[error]string
+}{
+	byName: map[string]error{},
+	byErr:  map[error]string{},
+}
+
+// Register records sentinel errors under a name, conventionally the package
+// name and the name of the variable, e.g. "users.ErrNotFound", so they can be
+// looked up by name when errors cross process boundaries.
+//
+// Code generated by gen-errors registers the sentinels it reads in an init
+// function. Register panics if a name is registered twice for different
+// errors or if err can't be compared.
+func Register(name string, err error) {
+	if err == nil || !reflect.TypeOf(err).Comparable() {
+		panic(fmt.Sprintf("errors: can't register %T as %q", err, name))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registered, ok := registry.byName[name]; ok {
+		if registered == err {
+			return
+		}
+		panic(fmt.Sprintf("errors: %q is already registered", name))
+	}
+
+	registry.byName[name] = err
+	registry.byErr[err] = name
+}
+
+// Registered returns the sentinel error registered under name, or nil if
+// there isn't one.
+func Registered(name string) error {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return registry.byName[name]
+}
+
+// RegisteredNames returns the names of all registered sentinel errors in
+// lexical order.
+func RegisteredNames() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.byName))
+	for name := range registry.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registeredName returns the name of the sentinel err is. With equivalent,
+// it's also the sentinel err says it's equivalent to with its own Is method,
+// like the typed errors generated by gen-errors do.
+func registeredName(err error, equivalent bool) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if reflect.TypeOf(err).Comparable() {
+		if name, ok := registry.byErr[err]; ok {
+			return name, true
+		}
+	}
+
+	if x, ok := err.(interface{ Is(error) bool }); ok && equivalent {
+		names := make([]string, 0, len(registry.byName))
+		for name := range registry.byName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if x.Is(registry.byName[name]) {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,198 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// stackDepth is the maximum number of frames recorded per stack.
+var stackDepth atomic.Int32
+
+func init() {
+	stackDepth.Store(32)
+}
+
+// SetStackDepth sets the maximum number of frames recorded when an error is
+// created or wrapped. The default is 32. A depth of 0 disables recording
+// stacks altogether.
+func SetStackDepth(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	stackDepth.Store(int32(depth))
+}
+
+// stack is the program counters of a call stack. They're only resolved to
+// functions, files and lines when the stack is printed.
+type stack []uintptr
+
+// callers records the stack of the function that called the function calling
+// callers.
+func callers() *stack {
+	depth := stackDepth.Load()
+	if depth == 0 {
+		return nil
+	}
+
+	pcs := make([]uintptr, depth)
+	n := runtime.Callers(3, pcs)
+	s := stack(pcs[:n])
+	return &s
+}
+
+// frames resolves the program counters of the stack.
+func (s *stack) frames() Stack {
+	if s == nil || len(*s) == 0 {
+		return nil
+	}
+
+	st := make(Stack, 0, len(*s))
+	frames := runtime.CallersFrames(*s)
+	for {
+		frame, more := frames.Next()
+		st = append(st, Frame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+		if !more {
+			break
+		}
+	}
+
+	return st
+}
+
+func (s *stack) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		for _, frame := range s.frames() {
+			io.WriteString(f, "\n")
+			frame.Format(f, verb)
+		}
+	}
+}
+
+// Frame is a function call of a stack.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Format formats the frame according to the fmt.Formatter interface.
+//
+//	%s    source file
+//	%d    source line
+//	%n    function name
+//	%v    equivalent to %s:%d
+//
+// Format accepts flags that alter the printing of some verbs, as follows:
+//
+//	%+s   function name and path of source file separated by \n\t
+//	      (<funcname>\n\t<path>)
+//	%+v   equivalent to %+s:%d
+func (f Frame) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 's':
+		switch {
+		case s.Flag('+'):
+			io.WriteString(s, f.Function)
+			io.WriteString(s, "\n\t")
+			io.WriteString(s, f.File)
+		default:
+			io.WriteString(s, path.Base(f.File))
+		}
+	case 'd':
+		io.WriteString(s, strconv.Itoa(f.Line))
+	case 'n':
+		io.WriteString(s, funcname(f.Function))
+	case 'v':
+		f.Format(s, 's')
+		io.WriteString(s, ":")
+		f.Format(s, 'd')
+	}
+}
+
+// funcname removes the path prefix component of a function's name.
+func funcname(name string) string {
+	i := strings.LastIndex(name, "/")
+	name = name[i+1:]
+	i = strings.Index(name, ".")
+	return name[i+1:]
+}
+
+// Stack is a stack of Frames from innermost (newest) to outermost (oldest).
+type Stack []Frame
+
+// Format formats the stack of Frames according to the fmt.Formatter
+// interface.
+//
+//	%s	lists source files for each Frame in the stack
+//	%v	lists the source file and line number for each Frame in the stack
+//
+// Format accepts flags that alter the printing of some verbs, as follows:
+//
+//	%+v   Prints filename, function, and line number for each Frame in the stack.
+func (st Stack) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		switch {
+		case s.Flag('+'):
+			for _, f := range st {
+				io.WriteString(s, "\n")
+				f.Format(s, verb)
+			}
+		case s.Flag('#'):
+			fmt.Fprintf(s, "%#v", []Frame(st))
+		default:
+			st.formatSlice(s, verb)
+		}
+	case 's':
+		st.formatSlice(s, verb)
+	}
+}
+
+// formatSlice will format this Stack into the given buffer as a slice of
+// Frame, only valid when called with '%s' or '%v'.
+func (st Stack) formatSlice(s fmt.State, verb rune) {
+	io.WriteString(s, "[")
+	for i, f := range st {
+		if i > 0 {
+			io.WriteString(s, " ")
+		}
+		f.Format(s, verb)
+	}
+	io.WriteString(s, "]")
+}
+
+// stackTracer is implemented by the errors of this package that recorded a
+// stack.
+type stackTracer interface {
+	stackTrace() Stack
+}
+
+// StackTrace returns the deepest stack recorded in the chain of err, which is
+// the one closest to where the error originated. It returns nil if no stack
+// was recorded.
+func StackTrace(err error) Stack {
+	var tracers []stackTracer
+	for ; err != nil; err = Unwrap(err) {
+		if st, ok := err.(stackTracer); ok {
+			tracers = append(tracers, st)
+		}
+	}
+
+	// Only the stack that's returned is resolved to frames.
+	for i := len(tracers) - 1; i >= 0; i-- {
+		if st := tracers[i].stackTrace(); st != nil {
+			return st
+		}
+	}
+
+	return nil
+}
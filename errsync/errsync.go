@@ -2,15 +2,18 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// errsync is copied from the standard library with the only change being an
+// errsync is copied from the standard library with the only changes being an
 // added method called "Reset" that gives this package the ability to rerun the
-// function initially passed it after it's already been run.
+// function initially passed it after it's already been run, and panics being
+// returned as errors.
 
 package errsync
 
 import (
 	"sync"
 	"sync/atomic"
+
+	"github.com/rdeusser/x/errors"
 )
 
 // Once is an object that will perform exactly one action.
@@ -42,7 +45,7 @@ type Once struct {
 // Do to be called, it will deadlock.
 //
 // If f panics, Do considers it to have returned; future calls of Do return
-// without calling f.
+// without calling f. The panic is returned as an *errors.PanicError.
 //
 func (o *Once) Do(f func() error) error {
 	// Note: Here is an incorrect implementation of Do:
@@ -78,11 +81,12 @@ func (o *Once) Reset() {
 	atomic.StoreUint32(&o.done, 0)
 }
 
-func (o *Once) doSlow(f func() error) error {
+func (o *Once) doSlow(f func() error) (err error) {
 	o.m.Lock()
 	defer o.m.Unlock()
 	if o.done == 0 {
 		defer atomic.StoreUint32(&o.done, 1)
+		defer errors.Recover(&err)
 		return f()
 	}
 	return nil
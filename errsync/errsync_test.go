@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/rdeusser/x/errors"
 )
 
 func TestOnceReset(t *testing.T) {
@@ -39,3 +41,27 @@ func TestOnceReset(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 2, called)
 }
+
+func TestOncePanic(t *testing.T) {
+	var (
+		once   Once
+		called int
+	)
+
+	err := once.Do(func() error {
+		called++
+		panic("boom")
+	})
+
+	var p *errors.PanicError
+	assert.True(t, errors.As(err, &p))
+	assert.Equal(t, "boom", p.Value)
+
+	err = once.Do(func() error {
+		called++
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, called)
+}
@@ -5,6 +5,7 @@ go 1.20
 require (
 	github.com/fatih/color v1.16.0
 	github.com/google/gofuzz v1.2.0
+	github.com/mattn/go-isatty v0.0.20
 	github.com/scylladb/go-set v1.0.3-0.20200225121959-cc7b2070d91e
 	github.com/stretchr/testify v1.8.4
 	go.uber.org/goleak v1.3.0
@@ -16,7 +17,6 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/kr/pretty v0.3.1-0.20220308010035-d928460c8d68 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/mod v0.14.0 // indirect
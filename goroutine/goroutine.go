@@ -1,12 +1,18 @@
 package goroutine
 
-// Launch creates a goroutine and returns an error.
+import "github.com/rdeusser/x/errors"
+
+// Launch creates a goroutine and returns an error. If fn panics, the panic is
+// returned as an *errors.PanicError instead of crashing the process.
 func Launch(fn func() error) error {
 	errc := make(chan error, 1)
 
 	go func() {
-		err := fn()
-		errc <- err
+		var err error
+		defer func() { errc <- err }()
+		defer errors.Recover(&err)
+
+		err = fn()
 	}()
 
 	err := <-errc
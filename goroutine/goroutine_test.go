@@ -0,0 +1,23 @@
+package goroutine
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rdeusser/x/errors"
+)
+
+func TestLaunch(t *testing.T) {
+	assert.NoError(t, Launch(func() error { return nil }))
+	assert.Equal(t, io.EOF, Launch(func() error { return io.EOF }))
+
+	err := Launch(func() error {
+		panic("boom")
+	})
+
+	var p *errors.PanicError
+	assert.True(t, errors.As(err, &p))
+	assert.Equal(t, "panic: boom", err.Error())
+}
@@ -0,0 +1,409 @@
+// Package logrotate provides a log file writer that rotates the file once it
+// grows too big or a new day starts. It implements zapcore.WriteSyncer, so it
+// can be used as the sink of a zap logger.
+package logrotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp in the names of rotated
+// files. It sorts lexically and contains no characters that are awkward in
+// file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const megabyte = 1024 * 1024
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithMaxSize rotates the file before a write would make it bigger than
+// megabytes.
+func WithMaxSize(megabytes int) Option {
+	return func(w *Writer) {
+		w.maxSize = int64(megabytes) * megabyte
+	}
+}
+
+// WithDaily rotates the file on the first write of a new day.
+func WithDaily() Option {
+	return func(w *Writer) {
+		w.daily = true
+	}
+}
+
+// WithMaxBackups removes the oldest rotated files so that at most n are kept.
+// All of them are kept by default.
+func WithMaxBackups(n int) Option {
+	return func(w *Writer) {
+		w.maxBackups = n
+	}
+}
+
+// WithCompression gzips rotated files.
+func WithCompression() Option {
+	return func(w *Writer) {
+		w.compress = true
+	}
+}
+
+// Writer appends to a log file and rotates it as configured. Rotated files are
+// renamed to <name>-<timestamp><ext> next to the file, e.g.
+// app-2021-06-01T15-04-05.000.log, and gzipped in the background if
+// compression is enabled. Files rotated because a new day started are stamped
+// with the time they were opened, so the name tells which day they cover. If
+// the name is already taken, a counter is added to it, e.g.
+// app-2021-06-01T15-04-05.000.1.log.
+//
+// A Writer is safe for concurrent use.
+type Writer struct {
+	path       string
+	maxSize    int64
+	daily      bool
+	maxBackups int
+	compress   bool
+
+	// now returns the current time. It's replaced in tests.
+	now func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// millMu serializes compressing and removing rotated files, which runs
+	// in the background so it doesn't hold up writes.
+	millMu sync.Mutex
+	millWg sync.WaitGroup
+}
+
+// New returns a Writer appending to the file at path, which is created along
+// with its directory if it doesn't exist.
+func New(path string, opts ...Option) (*Writer, error) {
+	w := &Writer{
+		path: path,
+		now:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write writes p to the file, rotating it first if needed.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if stamp, ok := w.shouldRotate(len(p)); ok {
+		if err := w.rotate(stamp); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Sync commits the contents of the file to stable storage.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+
+	return w.file.Sync()
+}
+
+// Rotate rotates the file regardless of its size or age.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+
+	return w.rotate(w.now())
+}
+
+// Close closes the file and waits for rotated files to be compressed.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+
+	err := w.file.Close()
+	w.file = nil
+	w.millWg.Wait()
+
+	return err
+}
+
+// shouldRotate reports whether the file needs to be rotated before n bytes are
+// written to it and the time to stamp the rotated file with.
+func (w *Writer) shouldRotate(n int) (time.Time, bool) {
+	if w.daily {
+		y1, m1, d1 := w.openedAt.Date()
+		y2, m2, d2 := w.now().Date()
+		if y1 != y2 || m1 != m2 || d1 != d2 {
+			// Stamp the file with the day it covers, not the new one.
+			return w.openedAt, true
+		}
+	}
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(n) > w.maxSize {
+		return w.now(), true
+	}
+
+	return time.Time{}, false
+}
+
+// open opens the file for appending, picking up where a previous process left
+// off if it already exists.
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.openedAt = w.now()
+
+	// An existing file belongs to the day it was last written to.
+	if w.size > 0 {
+		w.openedAt = info.ModTime()
+	}
+
+	return nil
+}
+
+// rotate renames the file to a backup stamped with stamp and opens a new one.
+func (w *Writer) rotate(stamp time.Time) error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	backup := w.backupName(stamp)
+	if err := os.Rename(w.path, backup); err != nil {
+		// Keep writing to the old file rather than not at all.
+		if oerr := w.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.millWg.Add(1)
+	go func() {
+		defer w.millWg.Done()
+		w.mill(backup)
+	}()
+
+	return nil
+}
+
+// backupName returns the name of a rotated file stamped with t that isn't
+// taken yet, compressed or not. Rotating twice within a millisecond stamps
+// both files with the same time, so a counter is added to the name of the
+// second one instead of replacing the first.
+func (w *Writer) backupName(t time.Time) string {
+	dir, name := filepath.Split(w.path)
+	ext := filepath.Ext(name)
+	stamped := strings.TrimSuffix(name, ext) + "-" + t.Format(backupTimeFormat)
+
+	backup := filepath.Join(dir, stamped+ext)
+	for n := 1; exists(backup) || exists(backup+".gz"); n++ {
+		backup = filepath.Join(dir, stamped+"."+strconv.Itoa(n)+ext)
+	}
+
+	return backup
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// mill compresses the rotated file at backup if needed and removes the
+// oldest rotated files. Errors are ignored: there's nowhere to report them and
+// they'll be retried on the next rotation.
+func (w *Writer) mill(backup string) {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	if w.compress {
+		if err := compress(backup); err == nil {
+			os.Remove(backup)
+		}
+	}
+
+	if w.maxBackups <= 0 {
+		return
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		return
+	}
+
+	for len(backups) > w.maxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// backups returns the rotated files, oldest first.
+func (w *Writer) backups() ([]string, error) {
+	dir, name := filepath.Split(w.path)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		path  string
+		stamp time.Time
+		n     int
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, prefix)
+		t, n, ok := parseStamp(stamp)
+		if !ok {
+			continue
+		}
+
+		backups = append(backups, backup{
+			path:  filepath.Join(dir, name),
+			stamp: t,
+			n:     n,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].stamp.Equal(backups[j].stamp) {
+			return backups[i].stamp.Before(backups[j].stamp)
+		}
+		return backups[i].n < backups[j].n
+	})
+
+	paths := make([]string, len(backups))
+	for i, b := range backups {
+		paths[i] = b.path
+	}
+
+	return paths, nil
+}
+
+// parseStamp parses the timestamp of a rotated file and the counter that
+// follows it if its name was taken.
+func parseStamp(stamp string) (time.Time, int, bool) {
+	var n int
+	if len(stamp) > len(backupTimeFormat) {
+		if stamp[len(backupTimeFormat)] != '.' {
+			return time.Time{}, 0, false
+		}
+
+		var err error
+		if n, err = strconv.Atoi(stamp[len(backupTimeFormat)+1:]); err != nil || n < 1 {
+			return time.Time{}, 0, false
+		}
+		stamp = stamp[:len(backupTimeFormat)]
+	}
+
+	t, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	return t, n, true
+}
+
+// compress gzips the file at path to path.gz.
+func compress(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
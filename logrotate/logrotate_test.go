@@ -0,0 +1,280 @@
+package logrotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a fake clock that advances by a millisecond every time it's read so
+// rotated files get distinct names.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(time.Millisecond)
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newWriter(t *testing.T, c *clock, opts ...Option) (*Writer, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	w, err := New(path, opts...)
+	require.NoError(t, err)
+	w.now = c.Now
+	w.openedAt = c.Now()
+
+	return w, path
+}
+
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestRotateOnSize(t *testing.T) {
+	c := &clock{now: time.Date(2021, 6, 1, 12, 0, 0, 0, time.Local)}
+	w, path := newWriter(t, c, WithMaxSize(1))
+
+	line := bytes.Repeat([]byte("x"), megabyte/2)
+
+	for i := 0; i < 3; i++ {
+		_, err := w.Write(line)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	// Two halves fit in the first file, the third goes to a new one.
+	assert.Equal(t, []string{"app-2021-06-01T12-00-00.002.log", "app.log"}, files(t, filepath.Dir(path)))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(megabyte/2), info.Size())
+}
+
+func TestRotateDaily(t *testing.T) {
+	c := &clock{now: time.Date(2021, 6, 1, 23, 59, 0, 0, time.Local)}
+	w, path := newWriter(t, c, WithDaily())
+
+	_, err := w.Write([]byte("monday\n"))
+	require.NoError(t, err)
+
+	c.Add(time.Minute)
+
+	_, err = w.Write([]byte("tuesday\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// The rotated file is stamped with the day it covers.
+	names := files(t, filepath.Dir(path))
+	require.Len(t, names, 2)
+	assert.Equal(t, "app-2021-06-01T23-59-00.001.log", names[0])
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), names[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "monday\n", string(data))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "tuesday\n", string(data))
+}
+
+func TestRotateWithinMillisecond(t *testing.T) {
+	c := &clock{now: time.Date(2021, 6, 1, 12, 0, 0, 0, time.Local)}
+	w, path := newWriter(t, c, WithMaxBackups(3))
+
+	// The clock doesn't advance, so every backup gets the same timestamp.
+	now := c.Now()
+	w.now = func() time.Time { return now }
+
+	for _, s := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		require.NoError(t, w.Rotate())
+	}
+	require.NoError(t, w.Close())
+
+	backups, err := w.backups()
+	require.NoError(t, err)
+
+	// No backup replaced another one and only the oldest was removed.
+	var got []string
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		got = append(got, string(data))
+	}
+	assert.Equal(t, []string{"two\n", "three\n", "four\n"}, got)
+
+	assert.Equal(t, []string{
+		"app-2021-06-01T12-00-00.002.1.log",
+		"app-2021-06-01T12-00-00.002.2.log",
+		"app-2021-06-01T12-00-00.002.3.log",
+		"app.log",
+	}, files(t, filepath.Dir(path)))
+}
+
+func TestRotateDailyAfterSize(t *testing.T) {
+	c := &clock{now: time.Date(2021, 6, 1, 23, 59, 59, 0, time.Local)}
+	w, path := newWriter(t, c, WithDaily(), WithMaxSize(1))
+
+	now := c.Now()
+	w.now = func() time.Time { return now }
+
+	half := bytes.Repeat([]byte("x"), megabyte/2)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write(half)
+		require.NoError(t, err)
+	}
+
+	// The file opened by the size rotation is stamped with the same time
+	// when the day ends right after.
+	now = now.Add(time.Second)
+	_, err := w.Write([]byte("tuesday\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	names := files(t, filepath.Dir(path))
+	assert.Equal(t, []string{
+		"app-2021-06-01T23-59-59.002.1.log",
+		"app-2021-06-01T23-59-59.002.log",
+		"app.log",
+	}, names)
+
+	for name, size := range map[string]int64{
+		names[0]: megabyte / 2,
+		names[1]: megabyte,
+		names[2]: int64(len("tuesday\n")),
+	} {
+		info, err := os.Stat(filepath.Join(filepath.Dir(path), name))
+		require.NoError(t, err)
+		assert.Equal(t, size, info.Size(), name)
+	}
+}
+
+func TestParseStamp(t *testing.T) {
+	want := time.Date(2021, 6, 1, 12, 0, 0, 2e6, time.UTC)
+
+	for stamp, n := range map[string]int{
+		"2021-06-01T12-00-00.002":    0,
+		"2021-06-01T12-00-00.002.1":  1,
+		"2021-06-01T12-00-00.002.12": 12,
+	} {
+		got, gotN, ok := parseStamp(stamp)
+		require.True(t, ok, stamp)
+		assert.True(t, want.Equal(got), stamp)
+		assert.Equal(t, n, gotN, stamp)
+	}
+
+	for _, stamp := range []string{"", "2021-06-01", "2021-06-01T12-00-00.002.", "2021-06-01T12-00-00.002.0", "2021-06-01T12-00-00.002-1", "2021-06-01T12-00-00.002.x"} {
+		_, _, ok := parseStamp(stamp)
+		assert.False(t, ok, stamp)
+	}
+}
+
+func TestMaxBackupsAndCompression(t *testing.T) {
+	c := &clock{now: time.Date(2021, 6, 1, 12, 0, 0, 0, time.Local)}
+	w, path := newWriter(t, c, WithMaxBackups(2), WithCompression())
+
+	for _, s := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		require.NoError(t, w.Rotate())
+	}
+	require.NoError(t, w.Close())
+
+	names := files(t, filepath.Dir(path))
+	require.Len(t, names, 3)
+	assert.Equal(t, "app.log", names[2])
+
+	// Only the newest backups are kept.
+	for i, want := range []string{"three\n", "four\n"} {
+		assert.True(t, strings.HasSuffix(names[i], ".log.gz"), names[i])
+
+		f, err := os.Open(filepath.Join(filepath.Dir(path), names[i]))
+		require.NoError(t, err)
+
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+
+		f.Close()
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	c := &clock{now: time.Date(2021, 6, 1, 12, 0, 0, 0, time.Local)}
+	w, path := newWriter(t, c, WithMaxSize(1))
+
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 512; j++ {
+				_, err := w.Write(line)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, w.Close())
+
+	// Every line made it to exactly one file, whole.
+	var total int
+	for _, name := range files(t, filepath.Dir(path)) {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(data), megabyte)
+
+		for _, l := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+			assert.Len(t, l, 1023)
+			total++
+		}
+	}
+	assert.Equal(t, 8*512, total)
+}
+
+func TestClosed(t *testing.T) {
+	c := &clock{now: time.Now()}
+	w, _ := newWriter(t, c)
+	require.NoError(t, w.Close())
+
+	_, err := w.Write([]byte("x"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.ErrorIs(t, w.Close(), os.ErrClosed)
+}
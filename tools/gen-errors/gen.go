@@ -0,0 +1,573 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/rdeusser/x/errors"
+)
+
+var funcMap = template.FuncMap{
+	"cell": func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+	"join": strings.Join,
+}
+
+// placeholder matches the parameters in messages, e.g. "user {id} not found".
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// initialisms are the parameter names that are upper-cased as a whole when
+// they become field names.
+var initialisms = map[string]string{
+	"api":  "API",
+	"http": "HTTP",
+	"id":   "ID",
+	"ip":   "IP",
+	"json": "JSON",
+	"uri":  "URI",
+	"url":  "URL",
+	"uuid": "UUID",
+}
+
+type GeneratorOptions struct {
+	Args      []string
+	BuildTags string
+	Dir       string
+	Doc       string
+	Output    string
+}
+
+type Generator struct {
+	options GeneratorOptions
+	fset    *token.FileSet
+	pkgName string
+	errors  []Error
+}
+
+type Error struct {
+	Var         string
+	Type        string
+	Constructor string
+	Code        string
+	CodeName    string
+	HTTPStatus  int
+	Message     string
+	Format      string
+	FormatArgs  string
+	Params      []Param
+	Description string
+}
+
+type Param struct {
+	Name  string
+	Field string
+	Type  string
+}
+
+func NewGenerator(options GeneratorOptions) *Generator {
+	return &Generator{options: options}
+}
+
+func (g *Generator) Run() ([]byte, error) {
+	dir := g.options.Dir
+	if dir == "" {
+		dir = "."
+	}
+
+	ctx := build.Default
+	if g.options.BuildTags != "" {
+		ctx.BuildTags = strings.Split(g.options.BuildTags, ",")
+	}
+
+	pkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	g.fset = token.NewFileSet()
+	g.pkgName = pkg.Name
+
+	for _, name := range pkg.GoFiles {
+		file, err := parser.ParseFile(g.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := g.findErrors(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(g.errors) == 0 {
+		return nil, fmt.Errorf("no annotated errors found in %s", dir)
+	}
+
+	var needsFmt, hasParams bool
+	for _, e := range g.errors {
+		needsFmt = needsFmt || e.FormatArgs != ""
+		hasParams = hasParams || len(e.Params) > 0
+	}
+
+	data := struct {
+		Args        []string
+		Errors      []Error
+		HasParams   bool
+		NeedsFmt    bool
+		PackageName string
+	}{
+		Args:        g.options.Args,
+		Errors:      g.errors,
+		HasParams:   hasParams,
+		NeedsFmt:    needsFmt,
+		PackageName: g.pkgName,
+	}
+
+	var buf bytes.Buffer
+
+	if err := _tmpl.Execute(&buf, data); err != nil {
+		return buf.Bytes(), err
+	}
+
+	output := g.options.Output
+	if output == "" {
+		output = filepath.Join(dir, "errors_gen.go")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), err
+	}
+
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		return buf.Bytes(), err
+	}
+
+	if g.options.Doc != "" {
+		var doc bytes.Buffer
+
+		if err := _docTmpl.Execute(&doc, data); err != nil {
+			return src, err
+		}
+
+		if err := os.WriteFile(g.options.Doc, doc.Bytes(), 0o644); err != nil {
+			return src, err
+		}
+	}
+
+	return src, nil
+}
+
+// findErrors collects the sentinel errors of file that are annotated with a
+// trailing comment like
+//
+//	ErrUserNotFound = errors.New("user not found") // code=not_found, params="id int", message="user {id} not found"
+//
+// The code is required. The message defaults to the one of the sentinel and
+// name overrides the name of the generated type.
+func (g *Generator) findErrors(file *ast.File) error {
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.VAR {
+			// Sentinel errors need to be variables.
+			continue
+		}
+
+		for _, spec := range decl.Specs {
+			vspec := spec.(*ast.ValueSpec) // we've already determined this is a var
+			if vspec.Comment == nil || !strings.Contains(vspec.Comment.Text(), "code=") {
+				// Not annotated.
+				continue
+			}
+
+			pos := g.fset.Position(vspec.Pos())
+
+			if len(vspec.Names) != 1 || len(vspec.Values) != 1 {
+				return fmt.Errorf("%s: annotated errors must be declared one per line", pos)
+			}
+
+			msg, ok := sentinelMessage(vspec.Values[0])
+			if !ok {
+				return fmt.Errorf("%s: %s must be declared with errors.New", pos, vspec.Names[0].Name)
+			}
+
+			doc := vspec.Doc
+			if doc == nil && !decl.Lparen.IsValid() {
+				doc = decl.Doc
+			}
+
+			e, err := newError(vspec.Names[0].Name, msg, vspec.Comment.Text(), doc.Text())
+			if err != nil {
+				return fmt.Errorf("%s: %w", pos, err)
+			}
+
+			g.errors = append(g.errors, e)
+		}
+	}
+
+	return nil
+}
+
+// sentinelMessage returns the message of a call like errors.New("message").
+func sentinelMessage(expr ast.Expr) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || fun.Sel.Name != "New" {
+		return "", false
+	}
+
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	msg, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+
+	return msg, true
+}
+
+func newError(name, msg, annotation, doc string) (Error, error) {
+	fields, err := parseAnnotation(annotation)
+	if err != nil {
+		return Error{}, err
+	}
+
+	e := Error{
+		Var:         name,
+		Message:     msg,
+		Description: strings.Join(strings.Fields(doc), " "),
+	}
+
+	for key, value := range fields {
+		switch key {
+		case "code":
+			if !errors.IsErrorCode(value) {
+				return Error{}, fmt.Errorf("%s: unknown code %q", name, value)
+			}
+			code := errors.StringToErrorCode(value)
+
+			e.CodeName = value
+			e.Code = codeIdent(value)
+			e.HTTPStatus = code.HTTPStatus()
+		case "message":
+			e.Message = value
+		case "name":
+			e.Type = value
+		case "params":
+			if e.Params, err = parseParams(value); err != nil {
+				return Error{}, fmt.Errorf("%s: %w", name, err)
+			}
+		default:
+			return Error{}, fmt.Errorf("%s: unknown annotation %q", name, key)
+		}
+	}
+
+	if e.Code == "" {
+		return Error{}, fmt.Errorf("%s: code is required", name)
+	}
+
+	if e.Type == "" {
+		switch {
+		case strings.HasPrefix(name, "Err"):
+			e.Type = name[len("Err"):] + "Error"
+		case strings.HasPrefix(name, "err"):
+			e.Type = lowerFirst(name[len("err"):]) + "Error"
+		default:
+			e.Type = name + "Error"
+		}
+	}
+
+	if ast.IsExported(e.Type) {
+		e.Constructor = "New" + e.Type
+	} else {
+		e.Constructor = "new" + upperFirst(e.Type)
+	}
+
+	if err := e.compileMessage(); err != nil {
+		return Error{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return e, nil
+}
+
+// compileMessage turns the message into a format string for fmt.Sprintf and
+// the fields of the error that fill in its parameters.
+func (e *Error) compileMessage() error {
+	var (
+		format strings.Builder
+		args   []string
+		last   int
+	)
+
+	for _, m := range placeholder.FindAllStringSubmatchIndex(e.Message, -1) {
+		name := e.Message[m[2]:m[3]]
+
+		param, ok := e.param(name)
+		if !ok {
+			return fmt.Errorf("message refers to unknown parameter %q", name)
+		}
+
+		format.WriteString(strings.ReplaceAll(e.Message[last:m[0]], "%", "%%"))
+		format.WriteString("%v")
+		args = append(args, "e."+param.Field)
+		last = m[1]
+	}
+
+	if len(args) == 0 {
+		e.Format = strconv.Quote(e.Message)
+		return nil
+	}
+
+	format.WriteString(strings.ReplaceAll(e.Message[last:], "%", "%%"))
+
+	e.Format = strconv.Quote(format.String())
+	e.FormatArgs = strings.Join(args, ", ")
+	return nil
+}
+
+func (e Error) param(name string) (Param, bool) {
+	for _, p := range e.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+// Signature returns the parameters of the constructor, e.g. "id int, org string".
+func (e Error) Signature() string {
+	params := make([]string, len(e.Params))
+	for i, p := range e.Params {
+		params[i] = p.Name + " " + p.Type
+	}
+	return strings.Join(params, ", ")
+}
+
+// Literal returns the composite literal the constructor returns.
+func (e Error) Literal() string {
+	fields := make([]string, len(e.Params))
+	for i, p := range e.Params {
+		fields[i] = p.Field + ": " + p.Name
+	}
+	return e.Type + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// parseAnnotation parses comma-separated key=value pairs. Values containing
+// commas need to be quoted.
+func parseAnnotation(text string) (map[string]string, error) {
+	fields := map[string]string{}
+	text = strings.TrimSpace(text)
+
+	for text != "" {
+		idx := strings.Index(text, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("missing value in annotation %q", text)
+		}
+
+		key := strings.TrimSpace(text[:idx])
+		text = strings.TrimSpace(text[idx+1:])
+
+		var value string
+
+		if strings.HasPrefix(text, `"`) {
+			quoted, err := strconv.QuotedPrefix(text)
+			if err != nil {
+				return nil, fmt.Errorf("annotation %q: %w", key, err)
+			}
+
+			value, _ = strconv.Unquote(quoted)
+			text = text[len(quoted):]
+		} else {
+			end := strings.Index(text, ",")
+			if end < 0 {
+				end = len(text)
+			}
+
+			value = strings.TrimSpace(text[:end])
+			text = text[end:]
+		}
+
+		fields[key] = value
+
+		text = strings.TrimSpace(text)
+		text = strings.TrimSpace(strings.TrimPrefix(text, ","))
+	}
+
+	return fields, nil
+}
+
+// parseParams parses a parameter list like "id int, org string".
+func parseParams(text string) ([]Param, error) {
+	var params []Param
+
+	for _, field := range strings.Split(text, ",") {
+		field = strings.TrimSpace(field)
+
+		idx := strings.IndexFunc(field, unicode.IsSpace)
+		if idx < 0 {
+			return nil, fmt.Errorf("parameter %q needs a type", field)
+		}
+
+		p := Param{
+			Name: field[:idx],
+			Type: strings.TrimSpace(field[idx:]),
+		}
+
+		if !token.IsIdentifier(p.Name) {
+			return nil, fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+
+		if _, err := parser.ParseExpr(p.Type); err != nil {
+			return nil, fmt.Errorf("invalid type %q of parameter %q", p.Type, p.Name)
+		}
+
+		if field, ok := initialisms[p.Name]; ok {
+			p.Field = field
+		} else {
+			p.Field = upperFirst(p.Name)
+		}
+
+		params = append(params, p)
+	}
+
+	return params, nil
+}
+
+// codeIdent returns the name of the ErrorCode constant for a code name, e.g.
+// NotFound for not_found.
+func codeIdent(name string) string {
+	if name == "ok" {
+		return "OK"
+	}
+
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		words[i] = upperFirst(word)
+	}
+	return strings.Join(words, "")
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+var _tmpl = template.Must(template.New("").Funcs(funcMap).Parse(`// Code generated by "gen-errors {{ join .Args " " }}"; DO NOT EDIT.
+package {{ .PackageName }}
+
+import (
+	{{- if .NeedsFmt }}
+	"fmt"
+	{{- end }}
+
+	"github.com/rdeusser/x/errors"
+	{{- if .HasParams }}
+	"go.uber.org/zap"
+	{{- end }}
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	{{- range .Errors }}
+	errors.Register("{{ $.PackageName }}.{{ .Var }}", {{ .Var }})
+	{{- end }}
+}
+
+{{ range .Errors }}
+// {{ .Type }} is the typed form of {{ .Var }}.
+// errors.Is matches it against {{ .Var }} and it's classified as {{ .Code }}.
+{{- if .Params }}
+type {{ .Type }} struct {
+	{{- range .Params }}
+	{{ .Field }} {{ .Type }}
+	{{- end }}
+}
+{{- else }}
+type {{ .Type }} struct{}
+{{- end }}
+
+// {{ .Constructor }} returns a {{ .Type }}.
+func {{ .Constructor }}({{ .Signature }}) error {
+	return {{ .Literal }}
+}
+
+// Error returns the message of the error.
+func (e {{ .Type }}) Error() string {
+	{{- if .FormatArgs }}
+	return fmt.Sprintf({{ .Format }}, {{ .FormatArgs }})
+	{{- else }}
+	return {{ .Format }}
+	{{- end }}
+}
+
+// ErrorCode returns the code errors.Code classifies the error with.
+func (e {{ .Type }}) ErrorCode() errors.ErrorCode {
+	return errors.{{ .Code }}
+}
+
+// Is implements the inline interface (` + "`err.(interface{ Is(error) bool })`" + `) that
+// the standard libary errors.Is looks for in errors.
+func (e {{ .Type }}) Is(target error) bool {
+	return target == {{ .Var }}
+}
+
+// As implements the inline interface (` + "`err.(interface{ As(any) bool })`" + `) that
+// the standard libary errors.As looks for in errors, so a *{{ .Type }} can be
+// a target too.
+func (e {{ .Type }}) As(target interface{}) bool {
+	if t, ok := target.(**{{ .Type }}); ok {
+		*t = &e
+		return true
+	}
+	return false
+}
+
+// MarshalLogObject writes the message, code and parameters of the error, so
+// zap.Any("error", err) logs them as an object.
+func (e {{ .Type }}) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", e.Error())
+	enc.AddString("code", e.ErrorCode().String())
+	{{- range .Params }}
+	zap.Any("{{ .Name }}", e.{{ .Field }}).AddTo(enc)
+	{{- end }}
+	return nil
+}
+{{ end }}
+`))
+
+var _docTmpl = template.Must(template.New("").Funcs(funcMap).Parse(`<!-- Code generated by "gen-errors {{ join .Args " " }}"; DO NOT EDIT. -->
+
+# Errors of package {{ .PackageName }}
+
+| Error | Code | HTTP status | Message | Description |
+| --- | --- | --- | --- | --- |
+{{- range .Errors }}
+| ` + "`{{ .Var }}`" + ` | ` + "`{{ .CodeName }}`" + ` | {{ .HTTPStatus }} | ` + "`{{ cell .Message }}`" + ` | {{ cell .Description }} |
+{{- end }}
+`))
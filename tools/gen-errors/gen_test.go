@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestGenerator(t *testing.T) {
+	dir := t.TempDir()
+
+	generator := NewGenerator(GeneratorOptions{
+		Args:   []string{"-doc=ERRORS.md"},
+		Dir:    filepath.Join("testdata", "users"),
+		Doc:    filepath.Join(dir, "ERRORS.md"),
+		Output: filepath.Join(dir, "errors_gen.go"),
+	})
+
+	_, err := generator.Run()
+	require.NoError(t, err)
+
+	for _, name := range []string{"errors_gen.go", "ERRORS.md"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+
+		golden := filepath.Join("testdata", name+".golden")
+		if *update {
+			require.NoError(t, os.WriteFile(golden, got, 0o644))
+			continue
+		}
+
+		want, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got))
+	}
+}
+
+func TestParseAnnotation(t *testing.T) {
+	fields, err := parseAnnotation(`code=not_found, params="id int, org string", message="user {id}, of {org}"`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"code":    "not_found",
+		"params":  "id int, org string",
+		"message": "user {id}, of {org}",
+	}, fields)
+
+	_, err = parseAnnotation(`code`)
+	assert.Error(t, err)
+}
+
+func TestNewErrorInvalid(t *testing.T) {
+	testcases := map[string]string{
+		"unknown code":      `code=missing`,
+		"unknown key":       `code=not_found, color=red`,
+		"unknown parameter": `code=not_found, message="user {id} not found"`,
+		"missing type":      `code=not_found, params="id"`,
+		"no code":           `message="code=not_found"`,
+	}
+
+	for name, annotation := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newError("ErrUserNotFound", "user not found", annotation, "")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+var print bool
+
+func main() {
+	options := GeneratorOptions{
+		Args: os.Args[1:],
+	}
+
+	flag := flag.NewFlagSet("gen-errors", flag.ContinueOnError)
+
+	flag.StringVar(&options.Dir, "dir", ".", "directory of the package declaring the errors")
+	flag.StringVar(&options.Output, "output", "", "output file name; default srcdir/errors_gen.go")
+	flag.StringVar(&options.Doc, "doc", "", "write a Markdown table documenting the errors to this file")
+	flag.StringVar(&options.BuildTags, "tags", "", "comma-separated list of build tags to apply")
+	flag.BoolVar(&print, "print", false, "print the generated code to stdout")
+
+	if err := flag.Parse(os.Args[1:]); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	generator := NewGenerator(options)
+	src, err := generator.Run()
+	if err != nil {
+		if print {
+			fmt.Println(string(src))
+		}
+
+		log.Fatalf("%+v", err)
+	}
+
+	if print {
+		fmt.Println(string(src))
+	}
+}
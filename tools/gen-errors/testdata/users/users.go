@@ -0,0 +1,19 @@
+package users
+
+import "errors"
+
+//go:generate gen-errors -doc=ERRORS.md
+
+var (
+	// ErrUserNotFound is returned when there is no user with the requested ID.
+	ErrUserNotFound = errors.New("user not found") // code=not_found, params="id int, org string", message="user {id} not found in {org}"
+
+	// ErrQuotaExceeded is returned when a user has used up 100% of their quota.
+	ErrQuotaExceeded = errors.New("quota exceeded") // code=resource_exhausted, params="used float64", message="{used}% of the quota used"
+
+	// errLocked is returned when the account is locked | disabled.
+	errLocked = errors.New("account locked") // code=failed_precondition, name=lockedError
+
+	// ErrUnrelated isn't annotated.
+	ErrUnrelated = errors.New("unrelated")
+)
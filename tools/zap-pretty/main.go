@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/zappretty"
+)
+
+const pollInterval = 250 * time.Millisecond
+
+type printer struct {
+	mu      sync.Mutex
+	out     io.Writer
+	encoder zapcore.Encoder
+	keys    Keys
+	level   zapcore.Level
+}
+
+// printLine writes line to the output, prettified if it's a zap JSON entry and
+// untouched otherwise.
+func (p *printer) printLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, fields, ok := parseEntry(line, p.keys)
+	if !ok {
+		_, err := p.out.Write(line)
+		return err
+	}
+
+	if entry.Level < p.level {
+		return nil
+	}
+
+	buf, err := p.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	_, err = p.out.Write(buf.Bytes())
+	return err
+}
+
+func (p *printer) print(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if line[len(line)-1] != '\n' {
+				line = append(line, '\n')
+			}
+
+			if err := p.printLine(line); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// follower reads from a file and, instead of returning io.EOF, waits for more
+// data to be written to it.
+type follower struct {
+	f *os.File
+}
+
+func (f follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.f.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+func main() {
+	var (
+		keys       Keys
+		level      string
+		follow     bool
+		noColor    bool
+		forceColor bool
+	)
+
+	flag := flag.NewFlagSet("zap-pretty", flag.ContinueOnError)
+
+	flag.StringVar(&level, "level", "debug", "minimum level of entries to print")
+	flag.BoolVar(&follow, "f", false, "keep reading files as they grow, like tail -f")
+	flag.BoolVar(&noColor, "no-color", false, "disable colored output")
+	flag.BoolVar(&forceColor, "force-color", false, "color output even when it isn't a terminal")
+	flag.StringVar(&keys.Time, "time-key", "ts", "key of the entry time")
+	flag.StringVar(&keys.Level, "level-key", "level", "key of the entry level")
+	flag.StringVar(&keys.Name, "name-key", "logger", "key of the logger name")
+	flag.StringVar(&keys.Caller, "caller-key", "caller", "key of the entry caller")
+	flag.StringVar(&keys.Function, "function-key", "function", "key of the entry function")
+	flag.StringVar(&keys.Message, "message-key", "msg", "key of the entry message")
+	flag.StringVar(&keys.Stacktrace, "stacktrace-key", "stacktrace", "key of the entry stacktrace")
+
+	if err := flag.Parse(os.Args[1:]); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	var minLevel zapcore.Level
+	if err := minLevel.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	switch {
+	case noColor:
+		color.NoColor = true
+	case forceColor:
+		color.NoColor = false
+	}
+
+	p := &printer{
+		out:     os.Stdout,
+		encoder: zappretty.NewCLIEncoder(zap.NewProductionEncoderConfig()),
+		keys:    keys,
+		level:   minLevel,
+	}
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		hadError bool
+	)
+
+	for _, name := range files {
+		name := name
+
+		run := func() {
+			if err := printFile(p, name, follow); err != nil {
+				log.Printf("%s: %v", name, err)
+
+				mu.Lock()
+				hadError = true
+				mu.Unlock()
+			}
+		}
+
+		if !follow {
+			run()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run()
+		}()
+	}
+
+	wg.Wait()
+
+	if hadError {
+		os.Exit(1)
+	}
+}
+
+func printFile(p *printer, name string, follow bool) error {
+	if name == "-" {
+		return p.print(os.Stdin)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if follow {
+		return p.print(follower{f: f})
+	}
+
+	return p.print(f)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/zappretty"
+)
+
+// Keys holds the names of the JSON keys zap uses for the entry itself. All
+// other keys in a line are treated as fields.
+type Keys struct {
+	Time       string
+	Level      string
+	Name       string
+	Caller     string
+	Function   string
+	Message    string
+	Stacktrace string
+}
+
+// toField converts a decoded JSON value back into the zap field that most
+// likely produced it.
+func toField(key string, value interface{}) zapcore.Field {
+	switch v := value.(type) {
+	case string:
+		return zap.String(key, v)
+	case bool:
+		return zap.Bool(key, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return zap.Int64(key, i)
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return zap.Uint64(key, u)
+		}
+		f, _ := v.Float64()
+		return zap.Float64(key, f)
+	case zappretty.JSONObject:
+		return zap.Object(key, v)
+	case zappretty.JSONArray:
+		return zap.Array(key, v)
+	default:
+		return zap.Reflect(key, v)
+	}
+}
+
+// decodeObject decodes line as a single JSON object. It returns false if the
+// line is anything else, including an object followed by trailing data.
+func decodeObject(line []byte) (zappretty.JSONObject, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+
+	v, err := zappretty.DecodeJSON(line)
+	if err != nil {
+		return nil, false
+	}
+
+	obj, ok := v.(zappretty.JSONObject)
+	return obj, ok
+}
+
+// parseEntry reconstructs the entry and fields from a zap JSON line. It
+// returns false if the line isn't a zap entry, i.e. a JSON object with a
+// string message and level under the configured keys.
+func parseEntry(line []byte, keys Keys) (zapcore.Entry, []zapcore.Field, bool) {
+	var (
+		entry  zapcore.Entry
+		fields []zapcore.Field
+	)
+
+	obj, ok := decodeObject(line)
+	if !ok || !hasString(obj, keys.Message) || !hasString(obj, keys.Level) {
+		return entry, nil, false
+	}
+
+	entry.Level = zapcore.InfoLevel
+
+	for _, m := range obj {
+		s, isString := m.Value.(string)
+
+		switch {
+		case m.Key == keys.Time:
+			if ts, ok := parseTime(m.Value); ok {
+				entry.Time = ts
+				continue
+			}
+		case m.Key == keys.Level && isString:
+			var level zapcore.Level
+			if err := level.UnmarshalText([]byte(strings.ToLower(s))); err == nil {
+				entry.Level = level
+				continue
+			}
+		case m.Key == keys.Name && isString:
+			entry.LoggerName = s
+			continue
+		case m.Key == keys.Caller && isString:
+			entry.Caller = parseCaller(s)
+			continue
+		case m.Key == keys.Function && isString:
+			entry.Caller.Function = s
+			continue
+		case m.Key == keys.Message && isString:
+			entry.Message = s
+			continue
+		case m.Key == keys.Stacktrace && isString:
+			entry.Stack = s
+			continue
+		}
+
+		fields = append(fields, toField(m.Key, m.Value))
+	}
+
+	return entry, fields, true
+}
+
+// hasString reports whether obj has a string member named key.
+func hasString(obj zappretty.JSONObject, key string) bool {
+	for _, m := range obj {
+		if m.Key == key {
+			_, ok := m.Value.(string)
+			return ok
+		}
+	}
+	return false
+}
+
+// parseTime understands the encodings produced by zap's built-in time
+// encoders: seconds, milliseconds or nanoseconds since the epoch, and ISO8601
+// or RFC3339 strings.
+func parseTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		switch {
+		case math.Abs(f) >= 1e17:
+			return time.Unix(0, int64(f)), true
+		case math.Abs(f) >= 1e11:
+			return time.UnixMilli(int64(f)), true
+		default:
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)), true
+		}
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			if ts, err := time.Parse(layout, v); err == nil {
+				return ts, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseCaller splits a "path/to/file.go:42" caller back into its parts.
+func parseCaller(s string) zapcore.EntryCaller {
+	idx := strings.LastIndexByte(s, ':')
+	if idx < 0 {
+		return zapcore.EntryCaller{Defined: true, File: s}
+	}
+
+	line, err := strconv.Atoi(s[idx+1:])
+	if err != nil {
+		return zapcore.EntryCaller{Defined: true, File: s}
+	}
+
+	return zapcore.EntryCaller{
+		Defined: true,
+		File:    s[:idx],
+		Line:    line,
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/zappretty"
+)
+
+var productionKeys = Keys{
+	Time:       "ts",
+	Level:      "level",
+	Name:       "logger",
+	Caller:     "caller",
+	Function:   "function",
+	Message:    "msg",
+	Stacktrace: "stacktrace",
+}
+
+func TestParseEntry(t *testing.T) {
+	line := `{"level":"warn","ts":1700000000.5,"logger":"http.server","caller":"server/server.go:42",` +
+		`"function":"server.(*Server).Serve","msg":"slow request","stacktrace":"goroutine 1",` +
+		`"path":"/users","took":1.5,"status":503,"retry":true,"user":{"id":7,"name":"ann"},"tags":["a","b"]}`
+
+	entry, fields, ok := parseEntry([]byte(line), productionKeys)
+	require.True(t, ok)
+
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.Equal(t, time.Unix(1700000000, 5e8), entry.Time)
+	assert.Equal(t, "http.server", entry.LoggerName)
+	assert.Equal(t, zapcore.EntryCaller{
+		Defined:  true,
+		File:     "server/server.go",
+		Line:     42,
+		Function: "server.(*Server).Serve",
+	}, entry.Caller)
+	assert.Equal(t, "slow request", entry.Message)
+	assert.Equal(t, "goroutine 1", entry.Stack)
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	// Fields keep the order they were logged in.
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.Key
+	}
+	assert.Equal(t, []string{"path", "took", "status", "retry", "user", "tags"}, keys)
+
+	assert.Equal(t, map[string]interface{}{
+		"path":   "/users",
+		"took":   1.5,
+		"status": int64(503),
+		"retry":  true,
+		"user":   map[string]interface{}{"id": int64(7), "name": "ann"},
+		"tags":   []interface{}{"a", "b"},
+	}, enc.Fields)
+}
+
+func TestParseEntryFallbacks(t *testing.T) {
+	testcases := []struct {
+		name   string
+		line   string
+		level  zapcore.Level
+		fields []string
+	}{
+		{
+			name:  "upper-case level",
+			line:  `{"level":"ERROR","msg":"hello"}`,
+			level: zapcore.ErrorLevel,
+		},
+		{
+			name:   "unknown level is a field",
+			line:   `{"level":"loud","msg":"hello"}`,
+			level:  zapcore.InfoLevel,
+			fields: []string{"level"},
+		},
+		{
+			name:   "unparsable time is a field",
+			line:   `{"level":"info","ts":"yesterday","msg":"hello"}`,
+			level:  zapcore.InfoLevel,
+			fields: []string{"ts"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			entry, fields, ok := parseEntry([]byte(tc.line), productionKeys)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.level, entry.Level)
+
+			var keys []string
+			for _, f := range fields {
+				keys = append(keys, f.Key)
+			}
+			assert.Equal(t, tc.fields, keys)
+		})
+	}
+}
+
+func TestParseEntryPassThrough(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"plain text\n",
+		"[1, 2, 3]\n",
+		`"a string"`,
+		`{"msg":"truncated"`,
+		`{"msg":"one"} {"msg":"two"}`,
+		`{"msg":"one"} trailing`,
+		// JSON objects that aren't zap entries.
+		`{"foo":1}`,
+		`{"msg":"no level"}`,
+		`{"level":"info"}`,
+		`{"level":"info","msg":42}`,
+		`{"level":1,"msg":"numeric level"}`,
+	} {
+		_, _, ok := parseEntry([]byte(line), productionKeys)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	testcases := []struct {
+		name  string
+		value interface{}
+		want  time.Time
+		ok    bool
+	}{
+		{
+			name:  "epoch seconds",
+			value: json.Number("1700000000"),
+			want:  time.Unix(1700000000, 0),
+			ok:    true,
+		},
+		{
+			name:  "epoch seconds with fraction",
+			value: json.Number("1700000000.25"),
+			want:  time.Unix(1700000000, 25e7),
+			ok:    true,
+		},
+		{
+			name:  "epoch milliseconds",
+			value: json.Number("1700000000123"),
+			want:  time.UnixMilli(1700000000123),
+			ok:    true,
+		},
+		{
+			name:  "epoch nanoseconds",
+			value: json.Number("1700000000123456789"),
+			want:  time.Unix(0, 1700000000123456768), // rounded by float64
+			ok:    true,
+		},
+		{
+			name:  "RFC3339",
+			value: "2023-11-14T22:13:20Z",
+			want:  time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+			ok:    true,
+		},
+		{
+			name:  "RFC3339 with nanoseconds",
+			value: "2023-11-14T22:13:20.123456789Z",
+			want:  time.Date(2023, 11, 14, 22, 13, 20, 123456789, time.UTC),
+			ok:    true,
+		},
+		{
+			name:  "ISO8601",
+			value: "2023-11-14T22:13:20.123+0100",
+			want:  time.Date(2023, 11, 14, 21, 13, 20, 123e6, time.UTC),
+			ok:    true,
+		},
+		{
+			name:  "not a time",
+			value: "yesterday",
+		},
+		{
+			name:  "not a number",
+			value: json.Number("1e"),
+		},
+		{
+			name:  "bool",
+			value: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, ok := parseTime(tc.value)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.True(t, tc.want.Equal(ts), "want %s, got %s", tc.want, ts)
+			}
+		})
+	}
+}
+
+func TestParseCaller(t *testing.T) {
+	testcases := []struct {
+		caller string
+		file   string
+		line   int
+	}{
+		{caller: "server/server.go:42", file: "server/server.go", line: 42},
+		{caller: "/src/app/main.go:7", file: "/src/app/main.go", line: 7},
+		{caller: `C:\src\app\main.go:7`, file: `C:\src\app\main.go`, line: 7},
+		{caller: "main.go", file: "main.go"},
+		{caller: "main.go:", file: "main.go:"},
+		{caller: "main.go:x", file: "main.go:x"},
+	}
+
+	for _, tc := range testcases {
+		caller := parseCaller(tc.caller)
+		assert.True(t, caller.Defined, tc.caller)
+		assert.Equal(t, tc.file, caller.File, tc.caller)
+		assert.Equal(t, tc.line, caller.Line, tc.caller)
+	}
+}
+
+func TestDecodeObject(t *testing.T) {
+	obj, ok := decodeObject([]byte(`  {"b":1,"a":{"z":null,"y":[true,"x",1.5]}}` + "\n"))
+	require.True(t, ok)
+
+	assert.Equal(t, zappretty.JSONObject{
+		{Key: "b", Value: json.Number("1")},
+		{Key: "a", Value: zappretty.JSONObject{
+			{Key: "z", Value: nil},
+			{Key: "y", Value: zappretty.JSONArray{true, "x", json.Number("1.5")}},
+		}},
+	}, obj)
+
+	for _, line := range []string{"", "  ", "null", "[{}]", "{", `{"a":1}}`, `{1:2}`} {
+		_, ok := decodeObject([]byte(line))
+		assert.False(t, ok, line)
+	}
+}
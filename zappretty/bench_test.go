@@ -0,0 +1,107 @@
+package zappretty
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type benchObject struct {
+	depth int
+}
+
+func (o benchObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", "object")
+	enc.AddInt("depth", o.depth)
+	if o.depth > 0 {
+		return enc.AddObject("child", benchObject{depth: o.depth - 1})
+	}
+	return nil
+}
+
+func benchFields(n int) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, n)
+	for i := 0; len(fields) < n; i++ {
+		switch i % 5 {
+		case 0:
+			fields = append(fields, zap.String("string", "hello world"))
+		case 1:
+			fields = append(fields, zap.Int("int", i))
+		case 2:
+			fields = append(fields, zap.Float64("float", float64(i)/3))
+		case 3:
+			fields = append(fields, zap.Bool("bool", i%2 == 0))
+		case 4:
+			fields = append(fields, zap.Duration("duration", time.Duration(i)*time.Millisecond))
+		}
+	}
+	return fields
+}
+
+func BenchmarkEncodeEntry(b *testing.B) {
+	entry := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Now(),
+		LoggerName: "bench",
+		Message:    "benchmarking the encoder",
+		Caller:     zapcore.NewEntryCaller(0, "github.com/rdeusser/x/zappretty/bench_test.go", 42, true),
+	}
+
+	benchmarks := []struct {
+		name   string
+		fields []zapcore.Field
+	}{
+		{name: "0 fields"},
+		{name: "5 fields", fields: benchFields(5)},
+		{name: "50 fields", fields: benchFields(50)},
+		{name: "nested objects", fields: []zapcore.Field{zap.Object("object", benchObject{depth: 5})}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			encoder := NewCLIEncoder(zap.NewDevelopmentEncoderConfig())
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				buf, err := encoder.EncodeEntry(entry, bm.fields)
+				if err != nil {
+					b.Fatal(err)
+				}
+				buf.Free()
+			}
+		})
+	}
+}
+
+func TestEncodeEntryAllocs(t *testing.T) {
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig())
+	entry := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       epoch,
+		LoggerName: "allocs",
+		Message:    "counting allocations",
+		Caller:     zapcore.NewEntryCaller(0, "github.com/rdeusser/x/zappretty/bench_test.go", 42, true),
+	}
+	fields := []zapcore.Field{
+		zap.String("string", "hello world"),
+		zap.Int("int", 42),
+		zap.Float64("float", 1.5),
+		zap.Bool("bool", true),
+		zap.Object("object", benchObject{depth: 3}),
+	}
+
+	// Durations and times aren't included because the allocations of the
+	// configured EncodeDuration and EncodeTime are out of our control.
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ := encoder.EncodeEntry(entry, fields)
+		buf.Free()
+	})
+
+	if allocs > 0 {
+		t.Errorf("EncodeEntry allocated %v times per entry, want 0", allocs)
+	}
+}
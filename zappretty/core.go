@@ -0,0 +1,74 @@
+package zappretty
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// NewCLICore returns a core that writes entries encoded with the CLI encoder
+// to ws if enab is enabled at their level. It's what zapcore.NewCore with
+// NewCLIEncoder returns, except that errors added to loggers with With, e.g.
+//
+//	logger.With(zap.Error(err)).Info("retrying")
+//
+// get their fields and hints written like the errors of entries do. Cores
+// built with zapcore.NewCore only pass their message and details on to the
+// encoder.
+func NewCLICore(cfg zapcore.EncoderConfig, ws zapcore.WriteSyncer, enab zapcore.LevelEnabler, opts ...Option) zapcore.Core {
+	return &cliCore{
+		LevelEnabler: enab,
+		enc:          NewCLIEncoder(cfg, opts...).(*cliEncoder),
+		out:          ws,
+	}
+}
+
+type cliCore struct {
+	zapcore.LevelEnabler
+	enc *cliEncoder
+	out zapcore.WriteSyncer
+}
+
+func (c *cliCore) Level() zapcore.Level {
+	return zapcore.LevelOf(c.LevelEnabler)
+}
+
+func (c *cliCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone().(*cliEncoder)
+	enc.addFields(fields)
+
+	return &cliCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          enc,
+		out:          c.out,
+	}
+}
+
+func (c *cliCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *cliCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.out.Write(buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+
+	// Like zapcore.NewCore, sync before entries that end the program.
+	if entry.Level > zapcore.ErrorLevel {
+		_ = c.Sync()
+	}
+
+	return nil
+}
+
+func (c *cliCore) Sync() error {
+	return c.out.Sync()
+}
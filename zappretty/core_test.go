@@ -0,0 +1,48 @@
+package zappretty
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/errors"
+)
+
+func TestCLICoreContextErrors(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	core := NewCLICore(EncoderTestEncoderConfig(), zapcore.AddSync(&buf), zapcore.InfoLevel, WithRedaction(DefaultRedactionPolicy()))
+	logger := zap.New(core)
+
+	err := errors.WithHint(errors.With(io.EOF, "tenant", "acme", "password", "hunter2"), "check the connection")
+
+	logger.With(zap.Error(err)).Info("retrying", zap.Int("attempt", 2))
+	logger.Debug("hidden")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"error": "EOF", "errorFields": {"password": "[REDACTED]", "tenant": "acme"}, "attempt": 2`)
+	assert.Equal(t, "hint: check the connection", lines[1])
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestNewCLIContextErrors(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	logger, sync := NewCLI(WithOutput(zapcore.AddSync(&buf)))
+	defer sync()
+
+	logger.With(zap.Error(errors.With(io.EOF, "tenant", "acme"))).Info("retrying")
+	assert.Contains(t, buf.String(), `"errorFields": {"tenant": "acme"}`)
+}
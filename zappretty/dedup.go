@@ -0,0 +1,197 @@
+package zappretty
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// dedupEncoderConfig is used to encode entries into the keys they're compared
+// by. Only the parts that make two entries identical are included.
+var dedupEncoderConfig = zapcore.EncoderConfig{
+	MessageKey:  "msg",
+	LevelKey:    "level",
+	NameKey:     "logger",
+	EncodeLevel: zapcore.LowercaseLevelEncoder,
+}
+
+// NewDedupCore wraps core so that consecutive identical entries, i.e. entries
+// with the same level, logger name, message and fields, are collapsed.
+//
+// The first entry of a run is written right away. Its repeats are held back
+// and written as a single entry with a "(repeated N×)" suffix, where N counts
+// the first entry too, as soon as a different entry is written, timeout has
+// passed since the first repeat, or the core is synced. Distinct entries are
+// never dropped and are written in order.
+//
+// Entries above error level are never held back since they end the program.
+//
+// Entries are written to core without going through its Check, so only its
+// level and the rules of NameFilter cores apply. Wrap other cores that decide
+// in Check, like samplers, around the dedup core instead.
+func NewDedupCore(core zapcore.Core, timeout time.Duration) zapcore.Core {
+	return &dedupCore{
+		Core:  core,
+		enc:   zapcore.NewJSONEncoder(dedupEncoderConfig),
+		state: &dedupState{timeout: timeout},
+	}
+}
+
+type dedupCore struct {
+	zapcore.Core
+
+	// enc holds the context of the core so it's part of the key of entries.
+	enc   zapcore.Encoder
+	state *dedupState
+}
+
+// dedupState is shared by a core and the cores derived from it with With, so
+// runs are detected across all of them.
+type dedupState struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	last    *dedupEntry
+	timer   *time.Timer
+
+	// generation is incremented whenever last changes so a timer that fires
+	// late doesn't flush a newer run.
+	generation int
+}
+
+type dedupEntry struct {
+	core   zapcore.Core
+	key    string
+	entry  zapcore.Entry
+	fields []zapcore.Field
+	count  int
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	return &dedupCore{
+		Core:  c.Core.With(fields),
+		enc:   enc,
+		state: c.state,
+	}
+}
+
+func (c *dedupCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.accepts(entry) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+// accepts reports whether the wrapped core would write entry. Write skips the
+// Check of the wrapped core, so the checks of NameFilter cores are kept this
+// way.
+func (c *dedupCore) accepts(entry zapcore.Entry) bool {
+	return accepts(c.Core, entry)
+}
+
+func (c *dedupCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	s := c.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.Level > zapcore.ErrorLevel {
+		return errors.Join(s.flush(), c.Core.Write(entry, fields))
+	}
+
+	key, err := c.key(entry, fields)
+	if err != nil {
+		return errors.Join(s.flush(), c.Core.Write(entry, fields))
+	}
+
+	if s.last != nil && s.last.key == key {
+		// zap reuses the backing array of fields once Write returns.
+		s.last.entry = entry
+		s.last.fields = append(s.last.fields[:0], fields...)
+		s.last.count++
+
+		if s.timer == nil {
+			generation := s.generation
+			s.timer = time.AfterFunc(s.timeout, func() { s.expire(generation) })
+		}
+
+		return nil
+	}
+
+	err = s.flush()
+	s.last = &dedupEntry{
+		core:   c.Core,
+		key:    key,
+		entry:  entry,
+		fields: append([]zapcore.Field(nil), fields...),
+		count:  1,
+	}
+
+	return errors.Join(err, c.Core.Write(entry, fields))
+}
+
+func (c *dedupCore) Sync() error {
+	s := c.state
+	s.mu.Lock()
+	err := s.flush()
+	s.mu.Unlock()
+
+	return errors.Join(err, c.Core.Sync())
+}
+
+func (c *dedupCore) key(entry zapcore.Entry, fields []zapcore.Field) (string, error) {
+	buf, err := c.enc.EncodeEntry(zapcore.Entry{
+		Level:      entry.Level,
+		LoggerName: entry.LoggerName,
+		Message:    entry.Message,
+	}, fields)
+	if err != nil {
+		return "", err
+	}
+
+	key := buf.String()
+	buf.Free()
+
+	return key, nil
+}
+
+// flush writes the repeats of the current run, if any, and ends the run. It
+// must be called with mu held.
+func (s *dedupState) flush() error {
+	last := s.last
+	s.last = nil
+	s.generation++
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	if last == nil || last.count < 2 {
+		return nil
+	}
+
+	entry := last.entry
+	entry.Message += " (repeated " + strconv.Itoa(last.count) + "×)"
+
+	return last.core.Write(entry, last.fields)
+}
+
+func (s *dedupState) expire(generation int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+
+	// There's nobody to return the error to; the next write goes through the
+	// same core and will surface a persistent failure.
+	_ = s.flush()
+}
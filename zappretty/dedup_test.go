@@ -0,0 +1,132 @@
+package zappretty
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func messages(logs *observer.ObservedLogs) []string {
+	var msgs []string
+	for _, entry := range logs.All() {
+		msgs = append(msgs, entry.Message)
+	}
+	return msgs
+}
+
+func TestDedup(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(NewDedupCore(core, time.Hour))
+
+	for i := 0; i < 37; i++ {
+		logger.Warn("retrying", zap.String("host", "db"))
+	}
+	logger.Warn("retrying", zap.String("host", "cache"))
+	logger.Info("connected")
+	logger.Info("connected")
+	logger.Info("done")
+
+	assert.Equal(t, []string{
+		"retrying",
+		"retrying (repeated 37×)",
+		"retrying",
+		"connected",
+		"connected (repeated 2×)",
+		"done",
+	}, messages(logs))
+
+	// The repeats keep their fields.
+	assert.Equal(t, "db", logs.All()[1].ContextMap()["host"])
+}
+
+func TestDedupContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(NewDedupCore(core, time.Hour))
+
+	logger.With(zap.Int("worker", 1)).Info("polling")
+	logger.With(zap.Int("worker", 2)).Info("polling")
+	logger.With(zap.Int("worker", 2)).Info("polling")
+	assert.NoError(t, logger.Sync())
+
+	assert.Equal(t, []string{"polling", "polling", "polling (repeated 2×)"}, messages(logs))
+	assert.Equal(t, int64(2), logs.All()[2].ContextMap()["worker"])
+}
+
+func TestDedupTimeout(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(NewDedupCore(core, 10*time.Millisecond))
+
+	logger.Info("tick")
+	logger.Info("tick")
+	logger.Info("tick")
+
+	assert.Eventually(t, func() bool { return logs.Len() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"tick", "tick (repeated 3×)"}, messages(logs))
+
+	// The run ended with the timeout, so the next one starts over.
+	logger.Info("tick")
+	assert.Equal(t, "tick", logs.All()[2].Message)
+}
+
+func TestDedupFilteringCore(t *testing.T) {
+	filter, err := ParseNameFilter("db=off, http=warn")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(NewDedupCore(NewNameFilterCore(core, filter, zapcore.InfoLevel), time.Hour))
+
+	logger.Named("db").Info("query")
+	logger.Named("db").Info("query")
+	logger.Named("http").Info("request")
+	logger.Named("http").Warn("slow request")
+	logger.Named("http").Warn("slow request")
+	logger.Info("done")
+
+	assert.Equal(t, []string{"slow request", "slow request (repeated 2×)", "done"}, messages(logs))
+}
+
+// checkCounter counts the calls to its Check.
+type checkCounter struct {
+	zapcore.Core
+	checks int
+}
+
+func (c *checkCounter) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	c.checks++
+	return c.Core.Check(entry, ce)
+}
+
+func TestDedupDoesntCheckWrappedCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	counter := &checkCounter{Core: core}
+	logger := zap.New(NewDedupCore(counter, time.Hour))
+
+	logger.Debug("hidden")
+	logger.Info("shown")
+
+	assert.Equal(t, 0, counter.checks)
+	assert.Equal(t, []string{"shown"}, messages(logs))
+}
+
+func TestDedupCopiesFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	dedup := NewDedupCore(core, time.Hour)
+
+	// zap reuses the slice of fields passed to Write.
+	fields := []zapcore.Field{zap.String("host", "db")}
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Message: "retrying"}
+
+	assert.NoError(t, dedup.Write(entry, fields))
+	assert.NoError(t, dedup.Write(entry, fields))
+	fields[0] = zap.String("host", "cache")
+	assert.NoError(t, dedup.Sync())
+
+	assert.Equal(t, []string{"retrying", "retrying (repeated 2×)"}, messages(logs))
+	assert.Equal(t, "db", logs.All()[1].ContextMap()["host"])
+}
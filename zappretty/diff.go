@@ -0,0 +1,439 @@
+package zappretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around changes.
+	diffContext = 3
+
+	// maxDiffCells bounds the work done to find the smallest diff, which
+	// takes a table of that many ints, e.g. two documents of 256 lines each.
+	// Bigger inputs are shown as entirely replaced.
+	maxDiffCells = 1 << 16
+)
+
+// Diff returns a field that compares the JSON forms of old and new.
+//
+// The CLI encoder writes a colored unified diff below the entry. Other
+// encoders get the changes as a JSON Patch (RFC 6902) array, with the
+// previous value of replaced and removed members in "old":
+//
+//	"spec": [{"op": "replace", "path": "/replicas", "old": 1, "value": 3}]
+//
+// Nothing is computed unless the entry is written.
+func Diff(key string, old, new interface{}) zap.Field {
+	return zap.Field{
+		Key:       key,
+		Type:      zapcore.ArrayMarshalerType,
+		Interface: &diff{old: old, new: new},
+	}
+}
+
+type diff struct {
+	old, new interface{}
+
+	once sync.Once
+	err  error
+
+	// oldJSON and newJSON are the indented JSON forms of old and new.
+	oldJSON, newJSON []byte
+	ops              []patchOp
+}
+
+func (d *diff) compute() error {
+	d.once.Do(func() {
+		var oldValue, newValue interface{}
+
+		d.oldJSON, oldValue, d.err = indentedJSON(d.old)
+		if d.err != nil {
+			return
+		}
+
+		d.newJSON, newValue, d.err = indentedJSON(d.new)
+		if d.err != nil {
+			return
+		}
+
+		d.ops = appendPatch(nil, "", oldValue, newValue)
+	})
+
+	return d.err
+}
+
+// MarshalLogArray writes the changes as JSON Patch operations.
+func (d *diff) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	if err := d.compute(); err != nil {
+		return err
+	}
+
+	for i := range d.ops {
+		if err := enc.AppendObject(&d.ops[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indentedJSON returns the indented JSON form of v along with its decoded
+// form, in which objects are maps and numbers are json.Numbers.
+func indentedJSON(v interface{}) ([]byte, interface{}, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, nil, err
+	}
+
+	return data, decoded, nil
+}
+
+// patchOp is a JSON Patch operation.
+type patchOp struct {
+	op     string
+	path   string
+	old    interface{}
+	hasOld bool
+	value  interface{}
+}
+
+func (p *patchOp) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("op", p.op)
+	enc.AddString("path", p.path)
+
+	if p.hasOld {
+		if err := enc.AddReflected("old", p.old); err != nil {
+			return err
+		}
+	}
+
+	if p.op != "remove" {
+		return enc.AddReflected("value", p.value)
+	}
+
+	return nil
+}
+
+// appendPatch appends the operations that turn a into b to ops. a and b are
+// decoded JSON values.
+func appendPatch(ops []patchOp, path string, a, b interface{}) []patchOp {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(a)+len(b))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range b {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			p := path + "/" + escapePointer(k)
+			av, inA := a[k]
+			bv, inB := b[k]
+
+			switch {
+			case !inB:
+				ops = append(ops, patchOp{op: "remove", path: p, old: av, hasOld: true})
+			case !inA:
+				ops = append(ops, patchOp{op: "add", path: p, value: bv})
+			default:
+				ops = appendPatch(ops, p, av, bv)
+			}
+		}
+
+		return ops
+
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		n := len(a)
+		if len(b) < n {
+			n = len(b)
+		}
+
+		for i := 0; i < n; i++ {
+			ops = appendPatch(ops, path+"/"+strconv.Itoa(i), a[i], b[i])
+		}
+
+		// Remove from the end so the indexes stay valid as the patch is
+		// applied.
+		for i := len(a) - 1; i >= n; i-- {
+			ops = append(ops, patchOp{op: "remove", path: path + "/" + strconv.Itoa(i), old: a[i], hasOld: true})
+		}
+
+		for i := n; i < len(b); i++ {
+			ops = append(ops, patchOp{op: "add", path: path + "/" + strconv.Itoa(i), value: b[i]})
+		}
+
+		return ops
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return ops
+	}
+
+	return append(ops, patchOp{op: "replace", path: path, old: a, hasOld: true, value: b})
+}
+
+// escapePointer escapes a member name for use in a JSON Pointer.
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// diffLine is a line of a unified diff. kind is ' ' for unchanged lines, '-'
+// for removed ones and '+' for added ones.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// hunk is a group of changes along with the lines around them.
+type hunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+	lines              []diffLine
+}
+
+// unifiedDiff returns the hunks of the unified diff of a and b.
+func unifiedDiff(a, b []string) []hunk {
+	lines := diffLines(a, b)
+
+	var hunks []hunk
+
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk as long as the next change is close enough for
+		// their context lines to overlap.
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		stop := end + diffContext + 1
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		h := hunk{lines: lines[start:stop]}
+		h.oldStart, h.newStart = 1, 1
+		for _, l := range lines[:start] {
+			if l.kind != '+' {
+				h.oldStart++
+			}
+			if l.kind != '-' {
+				h.newStart++
+			}
+		}
+		for _, l := range h.lines {
+			if l.kind != '+' {
+				h.oldLines++
+			}
+			if l.kind != '-' {
+				h.newLines++
+			}
+		}
+
+		hunks = append(hunks, h)
+		i = stop
+	}
+
+	return hunks
+}
+
+// diffLines returns the lines of a and b in order, each marked as unchanged,
+// removed or added, using the longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	lines := make([]diffLine, 0, len(a)+len(b))
+
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, l := range a {
+			lines = append(lines, diffLine{kind: '-', text: l})
+		}
+		for _, l := range b {
+			lines = append(lines, diffLine{kind: '+', text: l})
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{kind: ' ', text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{kind: '-', text: a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{kind: '+', text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{kind: '-', text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{kind: '+', text: b[j]})
+	}
+
+	return lines
+}
+
+// pendingDiff is a diff written below the entry it belongs to.
+type pendingDiff struct {
+	key string
+	d   *diff
+}
+
+// addDiff writes a summary of d in place of its value and queues the diff
+// itself to be written below the entry.
+func (enc *cliEncoder) addDiff(key string, d *diff) error {
+	enc.addKey(key)
+	enc.addElementSeparator()
+
+	if err := d.compute(); err != nil {
+		return err
+	}
+
+	n := len(d.ops)
+	switch n {
+	case 0:
+		enc.paint(enc.colors().elision, "(no changes)")
+		return nil
+	case 1:
+		enc.paint(enc.colors().elision, "(1 change, see below)")
+	default:
+		enc.on(enc.colors().elision)
+		enc.buf.AppendByte('(')
+		enc.buf.AppendInt(int64(n))
+		enc.buf.AppendString(" changes, see below)")
+		enc.off(enc.colors().elision)
+	}
+
+	enc.diffs = append(enc.diffs, pendingDiff{key: key, d: d})
+	return nil
+}
+
+// writeDiffs writes the queued diffs, each starting on a new line.
+func (enc *cliEncoder) writeDiffs() {
+	p := enc.colors()
+
+	for _, pending := range enc.diffs {
+		d := pending.d
+
+		enc.buf.AppendByte('\n')
+		enc.on(p.diffHeader)
+		enc.buf.AppendString("--- ")
+		enc.buf.AppendString(pending.key)
+		enc.buf.AppendString(" (old)")
+		enc.off(p.diffHeader)
+
+		enc.buf.AppendByte('\n')
+		enc.on(p.diffHeader)
+		enc.buf.AppendString("+++ ")
+		enc.buf.AppendString(pending.key)
+		enc.buf.AppendString(" (new)")
+		enc.off(p.diffHeader)
+
+		oldJSON, newJSON := d.oldJSON, d.newJSON
+		if r := enc.opts.redaction; r != nil {
+			oldJSON, newJSON = r.redactIndentedJSON(oldJSON), r.redactIndentedJSON(newJSON)
+		}
+
+		oldLines := strings.Split(string(oldJSON), "\n")
+		newLines := strings.Split(string(newJSON), "\n")
+
+		for _, h := range unifiedDiff(oldLines, newLines) {
+			enc.buf.AppendByte('\n')
+			enc.on(p.diffHunk)
+			enc.buf.AppendString("@@ -")
+			enc.buf.AppendInt(int64(h.oldStart))
+			enc.buf.AppendByte(',')
+			enc.buf.AppendInt(int64(h.oldLines))
+			enc.buf.AppendString(" +")
+			enc.buf.AppendInt(int64(h.newStart))
+			enc.buf.AppendByte(',')
+			enc.buf.AppendInt(int64(h.newLines))
+			enc.buf.AppendString(" @@")
+			enc.off(p.diffHunk)
+
+			for _, l := range h.lines {
+				enc.buf.AppendByte('\n')
+
+				var style sgr
+				switch l.kind {
+				case '-':
+					style = p.diffRemoved
+				case '+':
+					style = p.diffAdded
+				}
+
+				enc.on(style)
+				enc.buf.AppendByte(l.kind)
+				enc.buf.AppendString(l.text)
+				enc.off(style)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package zappretty
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/errors"
+)
+
+// addError adds field, an error logged with zap.Error, the way zap adds it.
+// zap only logs the message of errors, and the details %+v prints under
+// "<key>Verbose", so the fields attached to err with errors.With are added
+// under "<key>Fields" and its hints are queued. Errors whose key is redacted
+// are redacted as a whole.
+func (enc *cliEncoder) addError(field zapcore.Field, err error) {
+	if enc.shouldRedact(field.Key) {
+		enc.addRedacted(field.Key, err)
+		return
+	}
+
+	fields := errors.Fields(err)
+
+	// %+v prints the fields as they are, so the details are left out
+	// rather than leak what the fields hide.
+	for key := range fields {
+		if enc.shouldRedact(key) {
+			field.Interface = plainError{err}
+			break
+		}
+	}
+
+	field.AddTo(enc)
+
+	if len(fields) > 0 {
+		_ = enc.AddObject(field.Key+"Fields", errorFields(fields))
+	}
+
+	enc.addHints(err)
+}
+
+// plainError hides everything but the message of an error from zap.
+type plainError struct {
+	err error
+}
+
+func (e plainError) Error() string { return e.err.Error() }
+
+// errorFields writes the fields of an error sorted by key.
+type errorFields map[string]interface{}
+
+func (f errorFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		zap.Any(key, f[key]).AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package zappretty
+
+import (
+	"io"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/errors"
+)
+
+func TestErrorFields(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithRedaction(DefaultRedactionPolicy()))
+
+	err := errors.With(io.EOF, "tenant", "acme", "attempt", 3, "api_token", "abc")
+	err = errors.With(errors.Wrap(err, "reading response"), "request_id", "r-1")
+
+	out, encodeErr := encoder.EncodeEntry(zapcore.Entry{Time: epoch, Message: "failed"}, []zapcore.Field{
+		zap.Error(err),
+		zap.Error(io.ErrUnexpectedEOF),
+	})
+	require.NoError(t, encodeErr)
+
+	s := out.String()
+	assert.Contains(t, s, `"error": "reading response: EOF", "errorFields": {"api_token": "[REDACTED]", "attempt": 3, "request_id": "r-1", "tenant": "acme"}`)
+
+	// The details would print the token, so they're left out.
+	assert.NotContains(t, s, "abc")
+	assert.NotContains(t, s, "errorVerbose")
+
+	// Errors without fields are logged as before.
+	assert.Contains(t, s, `"error": "unexpected EOF" }`)
+}
+
+func TestErrorFieldsRedacted(t *testing.T) {
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithRedaction(RedactionPolicy{Keys: []string{"error"}}))
+
+	out, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch, Message: "failed"}, []zapcore.Field{
+		zap.Error(errors.With(io.EOF, "tenant", "acme")),
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, out.String(), "acme")
+	assert.NotContains(t, out.String(), "errorFields")
+}
+
+func TestErrorFieldsVerbose(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig())
+
+	out, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch, Message: "failed"}, []zapcore.Field{
+		zap.Error(errors.With(errors.New("not found"), "id", 7)),
+	})
+	require.NoError(t, err)
+
+	// Without secrets among the fields, the details are logged as usual.
+	assert.Contains(t, out.String(), `"errorVerbose": "not found`)
+	assert.Contains(t, out.String(), `"errorFields": {"id": 7}`)
+}
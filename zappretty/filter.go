@@ -0,0 +1,253 @@
+package zappretty
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// offLevel mutes the loggers a rule matches.
+const offLevel = zapcore.FatalLevel + 1
+
+// NameFilter decides which entries are written based on the name of the
+// logger they come from. It's configured with a spec of comma-separated rules
+// such as
+//
+//	info, http.*=warn, db=debug, grpc=off
+//
+// Each rule is a glob matched against logger names with path.Match, followed
+// by the lowest level that's written or "off". A rule also applies to the
+// descendants of the loggers it matches, so "db" covers "db.pool" too. A
+// level on its own is a rule matching every logger. When several rules
+// match, names win over globs and longer patterns win over shorter ones.
+//
+// The spec can be changed at any time with Set. NameFilter implements
+// flag.Value so it can be set from the command line.
+type NameFilter struct {
+	rules atomic.Pointer[nameRules]
+}
+
+type nameRules struct {
+	spec  string
+	rules []nameRule
+
+	// min is the lowest level any rule writes.
+	min zapcore.Level
+}
+
+type nameRule struct {
+	pattern string
+	glob    bool
+	level   zapcore.Level
+}
+
+// ParseNameFilter returns a NameFilter configured with spec.
+func ParseNameFilter(spec string) (*NameFilter, error) {
+	f := &NameFilter{}
+	if err := f.Set(spec); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// NameFilterFromEnv returns a NameFilter configured with the spec in the
+// environment variable key. A filter without rules is returned if it's unset.
+func NameFilterFromEnv(key string) (*NameFilter, error) {
+	f, err := ParseNameFilter(os.Getenv(key))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return f, nil
+}
+
+// Set replaces the rules of the filter with the ones in spec.
+func (f *NameFilter) Set(spec string) error {
+	r := &nameRules{spec: spec, min: offLevel}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pattern, levelName, ok := strings.Cut(part, "=")
+		if !ok {
+			pattern, levelName = "*", part
+		}
+		pattern = strings.TrimSpace(pattern)
+
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid logger name pattern %q", pattern)
+		}
+
+		level, err := parseFilterLevel(strings.TrimSpace(levelName))
+		if err != nil {
+			return err
+		}
+
+		r.rules = append(r.rules, nameRule{
+			pattern: pattern,
+			glob:    strings.ContainsAny(pattern, `*?[\`),
+			level:   level,
+		})
+
+		if level < r.min {
+			r.min = level
+		}
+	}
+
+	sort.SliceStable(r.rules, func(i, j int) bool {
+		a, b := r.rules[i], r.rules[j]
+		if a.glob != b.glob {
+			return !a.glob
+		}
+		return len(a.pattern) > len(b.pattern)
+	})
+
+	f.rules.Store(r)
+	return nil
+}
+
+// String returns the spec of the filter.
+func (f *NameFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	if r := f.rules.Load(); r != nil {
+		return r.spec
+	}
+	return ""
+}
+
+// Type returns the type of the flag, as pflag expects.
+func (f *NameFilter) Type() string {
+	return "filter"
+}
+
+// Level returns the level set for the logger called name by the first rule
+// that matches it. ok is false if none does.
+func (f *NameFilter) Level(name string) (level zapcore.Level, ok bool) {
+	r := f.rules.Load()
+	if r == nil {
+		return 0, false
+	}
+
+	for _, rule := range r.rules {
+		if rule.matches(name) {
+			return rule.level, true
+		}
+	}
+
+	return 0, false
+}
+
+// minLevel returns the lowest level any rule writes.
+func (f *NameFilter) minLevel() zapcore.Level {
+	if r := f.rules.Load(); r != nil {
+		return r.min
+	}
+	return offLevel
+}
+
+// matches reports whether the rule matches name or one of its ancestors.
+func (r nameRule) matches(name string) bool {
+	for {
+		if r.glob {
+			if ok, _ := path.Match(r.pattern, name); ok {
+				return true
+			}
+		} else if r.pattern == name {
+			return true
+		}
+
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+func parseFilterLevel(s string) (zapcore.Level, error) {
+	switch strings.ToLower(s) {
+	case "off", "none":
+		return offLevel, nil
+	}
+	return zapcore.ParseLevel(s)
+}
+
+// NewNameFilterCore wraps core so that entries are only written if filter
+// lets them through. Entries from loggers that no rule matches are written if
+// fallback is enabled at their level.
+//
+// Entries still have to get past core, so it should be enabled at every level
+// the filter or fallback might let through, which NewCLI and NewDevelopment
+// take care of with WithNameFilter.
+func NewNameFilterCore(core zapcore.Core, filter *NameFilter, fallback zapcore.LevelEnabler) zapcore.Core {
+	return &nameFilterCore{
+		Core:     core,
+		filter:   filter,
+		fallback: fallback,
+	}
+}
+
+type nameFilterCore struct {
+	zapcore.Core
+	filter   *NameFilter
+	fallback zapcore.LevelEnabler
+}
+
+func (c *nameFilterCore) Enabled(level zapcore.Level) bool {
+	if !c.Core.Enabled(level) {
+		return false
+	}
+	return c.fallback.Enabled(level) || level >= c.filter.minLevel()
+}
+
+func (c *nameFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &nameFilterCore{
+		Core:     c.Core.With(fields),
+		filter:   c.filter,
+		fallback: c.fallback,
+	}
+}
+
+func (c *nameFilterCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.accepts(entry) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+func (c *nameFilterCore) accepts(entry zapcore.Entry) bool {
+	if level, ok := c.filter.Level(entry.LoggerName); ok {
+		if entry.Level < level {
+			return false
+		}
+	} else if !c.fallback.Enabled(entry.Level) {
+		return false
+	}
+
+	return accepts(c.Core, entry)
+}
+
+// entryFilter is implemented by the cores of this package that decide in
+// Check whether to write an entry, so the cores wrapping them can ask without
+// calling Check, which has side effects.
+type entryFilter interface {
+	accepts(entry zapcore.Entry) bool
+}
+
+// accepts reports whether core would write entry, as far as it can tell
+// without calling its Check.
+func accepts(core zapcore.Core, entry zapcore.Entry) bool {
+	if f, ok := core.(entryFilter); ok {
+		return f.accepts(entry)
+	}
+	return core.Enabled(entry.Level)
+}
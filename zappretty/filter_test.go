@@ -0,0 +1,103 @@
+package zappretty
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNameFilterLevel(t *testing.T) {
+	filter, err := ParseNameFilter("info, http.*=warn, http.client=debug, db=debug, grpc=off")
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name  string
+		level zapcore.Level
+		ok    bool
+	}{
+		{name: "http.server", level: zapcore.WarnLevel, ok: true},
+		{name: "http.server.tls", level: zapcore.WarnLevel, ok: true},
+		{name: "http.client", level: zapcore.DebugLevel, ok: true},
+		{name: "http.client.pool", level: zapcore.DebugLevel, ok: true},
+		{name: "http", level: zapcore.InfoLevel, ok: true},
+		{name: "db.pool", level: zapcore.DebugLevel, ok: true},
+		{name: "grpc", level: offLevel, ok: true},
+		{name: "", level: zapcore.InfoLevel, ok: true},
+	}
+
+	for _, tc := range testcases {
+		level, ok := filter.Level(tc.name)
+		assert.Equal(t, tc.ok, ok, tc.name)
+		assert.Equal(t, tc.level, level, tc.name)
+	}
+
+	filter, err = ParseNameFilter("db=debug")
+	require.NoError(t, err)
+
+	_, ok := filter.Level("http")
+	assert.False(t, ok)
+}
+
+func TestNameFilterInvalid(t *testing.T) {
+	for _, spec := range []string{"db=loud", "[=info", "=info"} {
+		_, err := ParseNameFilter(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestNameFilterCore(t *testing.T) {
+	filter, err := ParseNameFilter("http.*=warn, db=debug")
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(NewNameFilterCore(core, filter, zapcore.InfoLevel))
+
+	logger.Named("http").Named("server").Info("request")
+	logger.Named("http").Named("server").Warn("slow request")
+	logger.Named("db").Debug("query")
+	logger.Named("cache").Debug("hit")
+	logger.Named("cache").Info("evicted")
+
+	assert.Equal(t, []string{"slow request", "query", "evicted"}, messages(logs))
+
+	// Changing the spec applies to loggers that already exist.
+	logs.TakeAll()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(filter, "log-filter", "")
+	require.NoError(t, fs.Parse([]string{"-log-filter", "db=off"}))
+
+	logger.Named("db").With(zap.Int("conn", 1)).Error("failed")
+	logger.Named("http").Info("request")
+
+	assert.Equal(t, []string{"request"}, messages(logs))
+	assert.Equal(t, "db=off", filter.String())
+}
+
+func TestNameFilterFromEnv(t *testing.T) {
+	t.Setenv("LOG_FILTER", "db=debug")
+
+	filter, err := NameFilterFromEnv("LOG_FILTER")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	logger, sync := NewCLI(WithNameFilter(filter), WithOutput(zapcore.AddSync(&buf)))
+	defer sync()
+
+	logger.Named("db").Debug("query")
+	logger.Named("http").Debug("request")
+	logger.Named("http").Info("served")
+
+	assert.Contains(t, buf.String(), "query")
+	assert.NotContains(t, buf.String(), "request")
+	assert.Contains(t, buf.String(), "served")
+
+	t.Setenv("LOG_FILTER", "db=loud")
+	_, err = NameFilterFromEnv("LOG_FILTER")
+	assert.ErrorContains(t, err, "LOG_FILTER")
+}
@@ -0,0 +1,182 @@
+package zappretty_test
+
+import (
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	xerrors "github.com/rdeusser/x/errors"
+	"github.com/rdeusser/x/zappretty"
+	"github.com/rdeusser/x/zappretty/zapprettytest"
+)
+
+// update is looked up by zapprettytest.AssertGolden.
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+type user struct {
+	Name   string   `json:"name"`
+	Groups []string `json:"groups"`
+	Admin  bool     `json:"admin"`
+}
+
+func TestGolden(t *testing.T) {
+	t.Setenv("FORCE_HYPERLINK", "1")
+
+	caller := zapcore.EntryCaller{
+		Defined:  true,
+		File:     "foo.go",
+		Line:     42,
+		Function: "foo.Bar",
+	}
+
+	testcases := []struct {
+		name    string
+		options []zappretty.Option
+		entry   zapcore.Entry
+		fields  []zapcore.Field
+	}{
+		{
+			name: "info with caller",
+			entry: zapcore.Entry{
+				Level:      zapcore.InfoLevel,
+				LoggerName: "main",
+				Message:    "hello world",
+				Caller:     caller,
+				Stack:      "foo",
+			},
+		},
+		{
+			name:  "warn",
+			entry: zapcore.Entry{Level: zapcore.WarnLevel, Message: "careful"},
+		},
+		{
+			name:  "error",
+			entry: zapcore.Entry{Level: zapcore.ErrorLevel, Message: "failed"},
+			fields: []zapcore.Field{
+				zap.Error(errors.New("boom")),
+			},
+		},
+		{
+			name:  "primitives",
+			entry: zapcore.Entry{Level: zapcore.DebugLevel, Message: "values"},
+			fields: []zapcore.Field{
+				zap.String("string", "hello"),
+				zap.Int("int", -1),
+				zap.Uint64("uint", 1),
+				zap.Float64("float", 3.14),
+				zap.Bool("bool", true),
+				zap.Duration("duration", 1500*time.Millisecond),
+				zap.Time("time", zapprettytest.Epoch),
+				zap.Binary("binary", []byte("bin\x00")),
+				zap.ByteString("bytestring", []byte("quote\"")),
+			},
+		},
+		{
+			name:  "nested",
+			entry: zapcore.Entry{Message: "nested"},
+			fields: []zapcore.Field{
+				zap.Strings("strings", []string{"a", "b"}),
+				zap.Reflect("user", user{Name: "bob", Groups: []string{"dev", "ops"}}),
+				zap.Namespace("http"),
+				zap.String("method", "GET"),
+			},
+		},
+		{
+			name:    "dotted namespaces",
+			options: []zappretty.Option{zappretty.WithDottedNamespaces()},
+			entry:   zapcore.Entry{Message: "dotted"},
+			fields: []zapcore.Field{
+				zap.Namespace("http"),
+				zap.String("method", "GET"),
+				zap.Int("status", 200),
+			},
+		},
+		{
+			name:    "limits",
+			options: []zappretty.Option{zappretty.WithLimits(zappretty.Limits{MaxStringLength: 4, MaxArrayElements: 1, MaxDepth: 1})},
+			entry:   zapcore.Entry{Message: "limited"},
+			fields: []zapcore.Field{
+				zap.String("string", "hello world"),
+				zap.Ints("ints", []int{1, 2, 3}),
+				zap.Reflect("user", user{Name: "bob", Groups: []string{"dev"}}),
+			},
+		},
+		{
+			name:    "redaction",
+			options: []zappretty.Option{zappretty.WithRedaction(zappretty.DefaultRedactionPolicy())},
+			entry:   zapcore.Entry{Message: "login"},
+			fields: []zapcore.Field{
+				zap.String("user", "bob"),
+				zap.String("password", "hunter2"),
+				zap.String("header", "Bearer abc.def"),
+			},
+		},
+		{
+			name:  "diff",
+			entry: zapcore.Entry{Level: zapcore.InfoLevel, Message: "reconciled"},
+			fields: []zapcore.Field{
+				zappretty.Diff("user",
+					user{Name: "bob", Groups: []string{"dev", "ops"}},
+					user{Name: "bob", Groups: []string{"dev", "sre"}, Admin: true},
+				),
+				zap.Int("generation", 2),
+			},
+		},
+		{
+			name:  "hints",
+			entry: zapcore.Entry{Level: zapcore.ErrorLevel, Message: "couldn't start"},
+			fields: []zapcore.Field{
+				zap.Error(xerrors.WithHint(errors.New("open /etc/app.yaml: permission denied"), "run `sudo app init` first")),
+			},
+		},
+		{
+			name:  "error_fields",
+			entry: zapcore.Entry{Level: zapcore.ErrorLevel, Message: "couldn't load user"},
+			fields: []zapcore.Field{
+				zap.Error(xerrors.With(errors.New("user not found"), "user_id", 42, "tenant", "acme")),
+			},
+		},
+		{
+			name:    "hyperlinks",
+			options: []zappretty.Option{zappretty.WithHyperlinks(zappretty.VSCodeURL)},
+			entry: zapcore.Entry{
+				Level:   zapcore.InfoLevel,
+				Message: "linked",
+				Caller: zapcore.EntryCaller{
+					Defined: true,
+					File:    "/src/my app/foo.go",
+					Line:    42,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.entry.Time = zapprettytest.Epoch
+
+			encoder := zappretty.NewCLIEncoder(zappretty.EncoderTestEncoderConfig(), tc.options...)
+
+			out, err := encoder.EncodeEntry(tc.entry, tc.fields)
+			assert.NoError(t, err)
+
+			zapprettytest.AssertGolden(t, out.Bytes())
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	s := zapprettytest.NewSnapshot(t)
+
+	logger := s.Logger().Named("snapshot").With(zap.String("request", "1"))
+	logger.Debug("starting")
+	logger.Info("started", zap.Int("workers", 4))
+	logger.Warn("slow", zap.Duration("elapsed", time.Second))
+
+	s.Assert()
+}
@@ -0,0 +1,39 @@
+package zappretty
+
+import (
+	"github.com/rdeusser/x/errors"
+)
+
+// addHints queues the hints attached to err with errors.WithHint, so they're
+// written below the entry where they can't be missed.
+func (enc *cliEncoder) addHints(err error) {
+	for _, hint := range errors.Hints(err) {
+		if !containsString(enc.hints, hint) {
+			enc.hints = append(enc.hints, hint)
+		}
+	}
+}
+
+// writeHints writes the queued hints, each on a line of its own.
+func (enc *cliEncoder) writeHints() {
+	for _, hint := range enc.hints {
+		if r := enc.opts.redaction; r != nil {
+			hint = r.redactString(hint)
+		}
+
+		enc.buf.AppendByte('\n')
+		enc.on(enc.colors().hint)
+		enc.buf.AppendString("hint: ")
+		enc.buf.AppendString(hint)
+		enc.off(enc.colors().hint)
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
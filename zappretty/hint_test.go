@@ -0,0 +1,47 @@
+package zappretty
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/errors"
+)
+
+func TestHints(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig())
+
+	// Hints of errors in the logger's context are written too, but only once.
+	err := errors.WithHint(io.EOF, "check the connection")
+	zap.Any("cause", err).AddTo(encoder)
+
+	out, encodeErr := encoder.EncodeEntry(zapcore.Entry{Time: epoch, Message: "failed"}, []zapcore.Field{
+		zap.Error(errors.WithHint(err, "retry later")),
+	})
+	require.NoError(t, encodeErr)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"error": "EOF"`)
+	assert.Equal(t, "hint: check the connection", lines[1])
+	assert.Equal(t, "hint: retry later", lines[2])
+}
+
+func TestHintsRedacted(t *testing.T) {
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithRedaction(RedactionPolicy{Keys: []string{"error"}}))
+
+	out, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch, Message: "failed"}, []zapcore.Field{
+		zap.Error(errors.WithHint(io.EOF, "use the token abc")),
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, out.String(), "hint")
+}
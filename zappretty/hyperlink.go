@@ -0,0 +1,151 @@
+package zappretty
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// FileURL links callers to the file they're in.
+	FileURL = "file://{file}"
+
+	// VSCodeURL links callers to the line they're on in Visual Studio Code.
+	VSCodeURL = "vscode://file{file}:{line}"
+
+	// osc8End ends the OSC 8 sequence that opens or closes a hyperlink.
+	osc8End = "\x1b\\"
+)
+
+// WithHyperlinks renders callers as hyperlinks in terminals that support
+// them. The link target is built from template by replacing {file} with the
+// path of the file as the caller reports it and {line} with the line number,
+// e.g. FileURL or VSCodeURL.
+//
+// Callers are written as plain text when colors are disabled or the terminal
+// isn't known to support hyperlinks. Set FORCE_HYPERLINK to 1 or 0 to
+// override the detection. Callers whose path isn't absolute, e.g. the ones of
+// binaries built with -trimpath, are written as plain text too, since a link
+// to them wouldn't lead anywhere.
+func WithHyperlinks(template string) Option {
+	return func(o *options) {
+		if hyperlinksSupported() {
+			o.hyperlink = parseLinkTemplate(template)
+		}
+	}
+}
+
+// hyperlinksSupported reports whether the terminal is known to support OSC 8
+// hyperlinks. Terminals that don't support them are supposed to ignore them,
+// but plenty print them as garbage, so it errs on the side of caution.
+func hyperlinksSupported() bool {
+	if force, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		return force != "0" && force != "false"
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+
+	if os.Getenv("TERM") == "xterm-kitty" || os.Getenv("WT_SESSION") != "" || os.Getenv("DOMTERM") != "" {
+		return true
+	}
+
+	// VTE terminals (GNOME Terminal, Tilix, ...) support them since 0.50.
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+
+	return false
+}
+
+// linkPart is either a literal part of a link template or a placeholder.
+type linkPart struct {
+	literal string
+	file    bool
+	line    bool
+}
+
+// linkTemplate is a parsed link template, split up so expanding it doesn't
+// allocate.
+type linkTemplate []linkPart
+
+func parseLinkTemplate(template string) linkTemplate {
+	var (
+		t       linkTemplate
+		literal strings.Builder
+	)
+
+	flush := func() {
+		if literal.Len() > 0 {
+			t = append(t, linkPart{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for template != "" {
+		switch {
+		case strings.HasPrefix(template, "{file}"):
+			flush()
+			t = append(t, linkPart{file: true})
+			template = template[len("{file}"):]
+		case strings.HasPrefix(template, "{line}"):
+			flush()
+			t = append(t, linkPart{line: true})
+			template = template[len("{line}"):]
+		default:
+			literal.WriteByte(template[0])
+			template = template[1:]
+		}
+	}
+	flush()
+
+	return t
+}
+
+// appendLinkStart opens a hyperlink to caller.
+func (t linkTemplate) appendLinkStart(buf *buffer.Buffer, caller zapcore.EntryCaller) {
+	buf.AppendString("\x1b]8;;")
+	for _, part := range t {
+		switch {
+		case part.file:
+			appendURLPath(buf, caller.File)
+		case part.line:
+			buf.AppendInt(int64(caller.Line))
+		default:
+			buf.AppendString(part.literal)
+		}
+	}
+	buf.AppendString(osc8End)
+}
+
+// appendLinkEnd closes the open hyperlink.
+func appendLinkEnd(buf *buffer.Buffer) {
+	buf.AppendString("\x1b]8;;" + osc8End)
+}
+
+// appendURLPath writes path percent-encoded so it's safe to use in a URL and
+// can't end the escape sequence it's in.
+func appendURLPath(buf *buffer.Buffer, path string) {
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c > ' ' && c < 0x7f && c != '%' && c != '#' && c != '?' {
+			buf.AppendByte(c)
+			continue
+		}
+		buf.AppendByte('%')
+		buf.AppendByte("0123456789ABCDEF"[c>>4])
+		buf.AppendByte("0123456789ABCDEF"[c&0xf])
+	}
+}
+
+// hyperlinks reports whether caller is written as a hyperlink.
+func (enc *cliEncoder) hyperlinks(caller zapcore.EntryCaller) bool {
+	return enc.opts.hyperlink != nil && !color.NoColor && filepath.IsAbs(caller.File)
+}
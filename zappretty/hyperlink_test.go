@@ -0,0 +1,95 @@
+package zappretty
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestHyperlinks(t *testing.T) {
+	entry := zapcore.Entry{
+		Time:    epoch,
+		Message: "linked",
+		Caller:  zapcore.EntryCaller{Defined: true, File: "/src/app/foo.go", Line: 42},
+	}
+	link := "\x1b]8;;file:///src/app/foo.go\x1b\\app/foo.go:42\x1b]8;;\x1b\\"
+
+	testcases := []struct {
+		name    string
+		force   string
+		noColor bool
+		want    bool
+	}{
+		{name: "supported", force: "1", want: true},
+		{name: "unsupported", force: "0"},
+		{name: "no color", force: "1", noColor: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("FORCE_HYPERLINK", tc.force)
+
+			noColor := color.NoColor
+			color.NoColor = tc.noColor
+			defer func() { color.NoColor = noColor }()
+
+			encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithHyperlinks(FileURL))
+
+			out, err := encoder.EncodeEntry(entry, nil)
+			assert.NoError(t, err)
+
+			if tc.want {
+				assert.Contains(t, out.String(), link)
+			} else {
+				assert.NotContains(t, out.String(), "\x1b]8;")
+				assert.Contains(t, out.String(), "(app/foo.go:42)")
+			}
+		})
+	}
+}
+
+func TestHyperlinkTruncated(t *testing.T) {
+	t.Setenv("FORCE_HYPERLINK", "1")
+
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithHyperlinks(FileURL), WithLimits(Limits{MaxLineWidth: 36}))
+
+	out, err := encoder.EncodeEntry(zapcore.Entry{
+		Time:   epoch,
+		Caller: zapcore.EntryCaller{Defined: true, File: "/src/app/foo.go", Line: 42},
+	}, []zapcore.Field{zap.String("k", "v")})
+	assert.NoError(t, err)
+
+	// The link is cut in the middle of its text, so it has to be closed.
+	assert.Contains(t, out.String(), "\\app\x1b]8;;\x1b\\\x1b[0m")
+}
+
+func TestParseLinkTemplate(t *testing.T) {
+	assert.Equal(t, linkTemplate{
+		{literal: "vscode://file"},
+		{file: true},
+		{literal: ":"},
+		{line: true},
+		{literal: "{col}"},
+	}, parseLinkTemplate("vscode://file{file}:{line}{col}"))
+}
+
+func TestHyperlinkRelativePath(t *testing.T) {
+	t.Setenv("FORCE_HYPERLINK", "1")
+
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithHyperlinks(VSCodeURL))
+
+	// Binaries built with -trimpath report paths relative to their module.
+	for _, file := range []string{"github.com/rdeusser/x/app/foo.go", "app/foo.go", "foo.go"} {
+		out, err := encoder.EncodeEntry(zapcore.Entry{
+			Time:   epoch,
+			Caller: zapcore.EntryCaller{Defined: true, File: file, Line: 42},
+		}, nil)
+		assert.NoError(t, err)
+
+		assert.NotContains(t, out.String(), "\x1b]8;", file)
+		assert.Contains(t, out.String(), "foo.go:42)", file)
+	}
+}
@@ -0,0 +1,196 @@
+package zappretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// JSONMember is a single key/value pair of a decoded JSON object.
+type JSONMember struct {
+	Key   string
+	Value interface{}
+}
+
+// JSONObject is a decoded JSON object. Unlike map[string]interface{} it retains
+// the order of its keys so values are printed the way they were marshaled.
+// It's a zapcore.ObjectMarshaler, so it can be logged with zap.Object.
+type JSONObject []JSONMember
+
+// JSONArray is a decoded JSON array. It's a zapcore.ArrayMarshaler, so it can
+// be logged with zap.Array.
+type JSONArray []interface{}
+
+func (o JSONObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, m := range o {
+		if err := addJSON(enc, m.Key, m.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the object with its members in order.
+func (o JSONObject) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, m := range o {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+	return append(buf, '}'), nil
+}
+
+func (a JSONArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, v := range a {
+		if err := appendJSON(enc, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addJSON(enc zapcore.ObjectEncoder, key string, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		enc.AddString(key, v)
+	case bool:
+		enc.AddBool(key, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			enc.AddInt64(key, i)
+		} else if u, err := parseUint(v); err == nil {
+			enc.AddUint64(key, u)
+		} else {
+			f, _ := v.Float64()
+			enc.AddFloat64(key, f)
+		}
+	case JSONObject:
+		return enc.AddObject(key, v)
+	case JSONArray:
+		return enc.AddArray(key, v)
+	default:
+		return enc.AddReflected(key, v)
+	}
+	return nil
+}
+
+func appendJSON(enc zapcore.ArrayEncoder, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		enc.AppendString(v)
+	case bool:
+		enc.AppendBool(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			enc.AppendInt64(i)
+		} else if u, err := parseUint(v); err == nil {
+			enc.AppendUint64(u)
+		} else {
+			f, _ := v.Float64()
+			enc.AppendFloat64(f)
+		}
+	case JSONObject:
+		return enc.AppendObject(v)
+	case JSONArray:
+		return enc.AppendArray(v)
+	default:
+		return enc.AppendReflected(v)
+	}
+	return nil
+}
+
+func parseUint(n json.Number) (uint64, error) {
+	return strconv.ParseUint(n.String(), 10, 64)
+}
+
+// DecodeJSON decodes data into strings, bools, json.Numbers, nils, JSONObjects
+// and JSONArrays. It returns an error if data isn't a single JSON value.
+func DecodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+
+	return v, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := JSONObject{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			key, ok := tok.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", tok)
+			}
+
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			obj = append(obj, JSONMember{Key: key, Value: value})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := JSONArray{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			arr = append(arr, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %v", delim)
+	}
+}
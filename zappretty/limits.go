@@ -0,0 +1,342 @@
+package zappretty
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Limits bounds how much of a value the CLI encoder writes. Anything past a
+// limit is replaced with an elision marker such as "…(+312 bytes)". A zero
+// limit means unlimited.
+type Limits struct {
+	// MaxStringLength is the maximum number of bytes written for strings
+	// and byte strings.
+	MaxStringLength int `json:"maxStringLength" yaml:"maxStringLength"`
+
+	// MaxArrayElements is the maximum number of elements written for
+	// arrays.
+	MaxArrayElements int `json:"maxArrayElements" yaml:"maxArrayElements"`
+
+	// MaxDepth is the maximum nesting depth of objects and arrays.
+	MaxDepth int `json:"maxDepth" yaml:"maxDepth"`
+
+	// MaxLineWidth is the maximum number of visible characters in a line,
+	// not counting color escape sequences.
+	MaxLineWidth int `json:"maxLineWidth" yaml:"maxLineWidth"`
+
+	// DisableForDebug writes debug entries in full regardless of the limits
+	// above.
+	DisableForDebug bool `json:"disableForDebug" yaml:"disableForDebug"`
+}
+
+// DefaultLimits returns limits that keep most entries on a single screen.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxStringLength:  256,
+		MaxArrayElements: 20,
+		MaxDepth:         5,
+	}
+}
+
+// WithLimits truncates values that exceed limits.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = &limits
+	}
+}
+
+// limits returns the limits that apply to the entry being encoded, or nil if
+// values are written in full.
+func (enc *cliEncoder) limits() *Limits {
+	if enc.unlimited {
+		return nil
+	}
+	return enc.opts.limits
+}
+
+// truncate shortens s to the maximum string length without splitting a rune.
+// It returns the number of bytes that were cut.
+func (enc *cliEncoder) truncate(s string) (string, int) {
+	l := enc.limits()
+	if l == nil || l.MaxStringLength <= 0 || len(s) <= l.MaxStringLength {
+		return s, 0
+	}
+
+	i := l.MaxStringLength
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+
+	return s[:i], len(s) - i
+}
+
+// truncateBytes is the []byte equivalent of truncate.
+func (enc *cliEncoder) truncateBytes(b []byte) ([]byte, int) {
+	l := enc.limits()
+	if l == nil || l.MaxStringLength <= 0 || len(b) <= l.MaxStringLength {
+		return b, 0
+	}
+
+	i := l.MaxStringLength
+	for i > 0 && !utf8.RuneStart(b[i]) {
+		i--
+	}
+
+	return b[:i], len(b) - i
+}
+
+// atMaxDepth reports whether another object or array would exceed the maximum
+// nesting depth.
+func (enc *cliEncoder) atMaxDepth() bool {
+	l := enc.limits()
+	return l != nil && l.MaxDepth > 0 && enc.depth >= l.MaxDepth
+}
+
+// appendElision appends a marker for n elided units of something.
+func (enc *cliEncoder) appendElision(n int, unit string) {
+	if n <= 0 {
+		return
+	}
+	enc.on(enc.colors().elision)
+	appendElision(enc.buf, n, unit)
+	enc.off(enc.colors().elision)
+}
+
+func appendElision(buf *buffer.Buffer, n int, unit string) {
+	buf.AppendString("…(+")
+	buf.AppendInt(int64(n))
+	buf.AppendByte(' ')
+	buf.AppendString(unit)
+	buf.AppendByte(')')
+}
+
+// truncateLine cuts the line in buf down to width visible characters and marks
+// the elision in the given style. Color escape sequences don't count towards
+// the width and are never split.
+func truncateLine(buf *buffer.Buffer, width int, style sgr) {
+	b := buf.Bytes()
+
+	var (
+		visible int
+		cut     = -1
+		elided  int
+
+		// linked is set when the cut is inside a hyperlink, which then
+		// has to be closed.
+		linked bool
+	)
+
+	for i := 0; i < len(b); {
+		if b[i] == '\x1b' {
+			n := escapeLen(b[i:])
+			if cut < 0 && n > 1 && b[i+1] == ']' {
+				linked = isLinkStart(b[i : i+n])
+			}
+			i += n
+			continue
+		}
+
+		_, size := utf8.DecodeRune(b[i:])
+
+		if visible == width && cut < 0 {
+			cut = i
+		}
+		if cut >= 0 {
+			elided += size
+		}
+
+		visible++
+		i += size
+	}
+
+	if cut < 0 {
+		return
+	}
+
+	buf.Reset()
+	buf.Write(b[:cut])
+
+	if linked {
+		appendLinkEnd(buf)
+	}
+
+	if !color.NoColor {
+		buf.AppendString("\x1b[0m")
+		buf.AppendString(style.on)
+	}
+
+	appendElision(buf, elided, "bytes")
+
+	if !color.NoColor {
+		buf.AppendString(style.off)
+	}
+}
+
+// escapeLen returns the length of the ANSI escape sequence at the start of b.
+func escapeLen(b []byte) int {
+	if len(b) >= 2 && b[1] == ']' {
+		// Operating system commands, such as hyperlinks, end with BEL or
+		// ESC \.
+		for i := 2; i < len(b); i++ {
+			if b[i] == '\a' {
+				return i + 1
+			}
+			if b[i] == '\x1b' && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(b)
+	}
+
+	if len(b) < 2 || b[1] != '[' {
+		return 1
+	}
+
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return i + 1
+		}
+	}
+
+	return len(b)
+}
+
+// isLinkStart reports whether the OSC sequence seq opens a hyperlink, as
+// opposed to closing one or being something else entirely.
+func isLinkStart(seq []byte) bool {
+	const prefix = "\x1b]8;"
+	if len(seq) < len(prefix) || string(seq[:len(prefix)]) != prefix {
+		return false
+	}
+
+	// The sequence is ESC ] 8 ; params ; URI ST, and the URI is empty when
+	// it closes the link.
+	rest := seq[len(prefix):]
+	for i, c := range rest {
+		if c == ';' {
+			uri := rest[i+1:]
+			return len(uri) > 0 && uri[0] != '\x1b' && uri[0] != '\a'
+		}
+	}
+	return false
+}
+
+// limitedArrayEncoder stops appending elements to an array once the limit has
+// been reached and counts the ones it dropped.
+type limitedArrayEncoder struct {
+	*cliEncoder
+	limit int
+	n     int
+}
+
+func (a *limitedArrayEncoder) next() bool {
+	a.n++
+	return a.n <= a.limit
+}
+
+// elided returns the number of elements that weren't written.
+func (a *limitedArrayEncoder) elided() int {
+	return a.n - a.limit
+}
+
+func (a *limitedArrayEncoder) AppendArray(value zapcore.ArrayMarshaler) error {
+	if !a.next() {
+		return nil
+	}
+	return a.cliEncoder.AppendArray(value)
+}
+
+func (a *limitedArrayEncoder) AppendObject(value zapcore.ObjectMarshaler) error {
+	if !a.next() {
+		return nil
+	}
+	return a.cliEncoder.AppendObject(value)
+}
+
+func (a *limitedArrayEncoder) AppendReflected(value interface{}) error {
+	if !a.next() {
+		return nil
+	}
+	return a.cliEncoder.AppendReflected(value)
+}
+
+func (a *limitedArrayEncoder) AppendBool(value bool) {
+	if a.next() {
+		a.cliEncoder.AppendBool(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendByteString(value []byte) {
+	if a.next() {
+		a.cliEncoder.AppendByteString(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendComplex128(value complex128) {
+	if a.next() {
+		a.cliEncoder.AppendComplex128(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendComplex64(value complex64) {
+	if a.next() {
+		a.cliEncoder.AppendComplex64(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendFloat64(value float64) {
+	if a.next() {
+		a.cliEncoder.AppendFloat64(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendFloat32(value float32) {
+	if a.next() {
+		a.cliEncoder.AppendFloat32(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendInt(value int)     { a.AppendInt64(int64(value)) }
+func (a *limitedArrayEncoder) AppendInt32(value int32) { a.AppendInt64(int64(value)) }
+func (a *limitedArrayEncoder) AppendInt16(value int16) { a.AppendInt64(int64(value)) }
+func (a *limitedArrayEncoder) AppendInt8(value int8)   { a.AppendInt64(int64(value)) }
+
+func (a *limitedArrayEncoder) AppendInt64(value int64) {
+	if a.next() {
+		a.cliEncoder.AppendInt64(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendString(value string) {
+	if a.next() {
+		a.cliEncoder.AppendString(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendUint(value uint)       { a.AppendUint64(uint64(value)) }
+func (a *limitedArrayEncoder) AppendUint32(value uint32)   { a.AppendUint64(uint64(value)) }
+func (a *limitedArrayEncoder) AppendUint16(value uint16)   { a.AppendUint64(uint64(value)) }
+func (a *limitedArrayEncoder) AppendUint8(value uint8)     { a.AppendUint64(uint64(value)) }
+func (a *limitedArrayEncoder) AppendUintptr(value uintptr) { a.AppendUint64(uint64(value)) }
+
+func (a *limitedArrayEncoder) AppendUint64(value uint64) {
+	if a.next() {
+		a.cliEncoder.AppendUint64(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendDuration(value time.Duration) {
+	if a.next() {
+		a.cliEncoder.AppendDuration(value)
+	}
+}
+
+func (a *limitedArrayEncoder) AppendTime(value time.Time) {
+	if a.next() {
+		a.cliEncoder.AppendTime(value)
+	}
+}
@@ -0,0 +1,108 @@
+package zappretty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type nested struct {
+	Child *nested `json:"child,omitempty"`
+}
+
+func TestLimits(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	limits := Limits{
+		MaxStringLength:  5,
+		MaxArrayElements: 2,
+		MaxDepth:         2,
+	}
+
+	testcases := []struct {
+		name  string
+		level zapcore.Level
+		field zapcore.Field
+		want  string
+	}{
+		{
+			name:  "string",
+			field: zap.String("s", "hello world"),
+			want:  `"s": "hello…(+6 bytes)"`,
+		},
+		{
+			name:  "string with multibyte rune",
+			field: zap.String("s", "hhhhé"),
+			want:  `"s": "hhhh…(+2 bytes)"`,
+		},
+		{
+			name:  "byte string",
+			field: zap.ByteString("b", []byte("hello world")),
+			want:  `"b": "hello…(+6 bytes)"`,
+		},
+		{
+			name:  "array",
+			field: zap.Ints("a", []int{1, 2, 3, 4, 5}),
+			want:  `"a": [1, 2, …(+3 elements)]`,
+		},
+		{
+			name:  "depth",
+			field: zap.Reflect("r", nested{Child: &nested{Child: &nested{Child: &nested{}}}}),
+			want:  `"r": {"child": {"child": {…}}}`,
+		},
+		{
+			name:  "reflected array",
+			field: zap.Reflect("r", []string{"a", "b", "c"}),
+			want:  `"r": ["a", "b", …(+1 elements)]`,
+		},
+		{
+			name:  "debug",
+			level: zapcore.DebugLevel,
+			field: zap.String("s", "hello world"),
+			want:  `"s": "hello world"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := limits
+			limits.DisableForDebug = true
+
+			encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithLimits(limits))
+
+			out, err := encoder.EncodeEntry(zapcore.Entry{Level: tc.level, Time: epoch}, []zapcore.Field{tc.field})
+			assert.NoError(t, err)
+			assert.Contains(t, out.String(), tc.want)
+		})
+	}
+}
+
+func TestLineWidth(t *testing.T) {
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithLimits(Limits{MaxLineWidth: 40}))
+
+	out, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch, Message: strings.Repeat("x", 100)}, nil)
+	assert.NoError(t, err)
+
+	stripped := stripEscapes(out.String())
+	assert.True(t, strings.HasPrefix(stripped, "[1970-01-01 00:00:00 UTC] INFO  xxxxxxxx…(+"), stripped)
+	assert.True(t, strings.HasSuffix(stripped, " bytes)\n"), stripped)
+	assert.True(t, strings.HasSuffix(out.String(), "\x1b[0m\n"))
+}
+
+func stripEscapes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			i += escapeLen([]byte(s[i:]))
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,238 @@
+package zappretty
+
+import (
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggerOption configures a logger built by NewCLI or NewDevelopment.
+type LoggerOption func(*loggerOptions)
+
+type loggerOptions struct {
+	level       zap.AtomicLevel
+	caller      bool
+	stacktrace  zapcore.Level
+	sampling    *zap.SamplingConfig
+	dedup       time.Duration
+	filter      *NameFilter
+	development bool
+	output      zapcore.WriteSyncer
+	encoderOpts []Option
+	zapOpts     []zap.Option
+}
+
+// WithLevel logs entries at level and above.
+func WithLevel(level zapcore.Level) LoggerOption {
+	return func(o *loggerOptions) {
+		o.level = zap.NewAtomicLevelAt(level)
+	}
+}
+
+// WithAtomicLevel logs entries at level and above. Changing level changes the
+// level of the logger while it's running.
+func WithAtomicLevel(level zap.AtomicLevel) LoggerOption {
+	return func(o *loggerOptions) {
+		o.level = level
+	}
+}
+
+// WithLevelFlag logs entries at the level of flag, so it can be set from the
+// command line and changed while the logger is running.
+func WithLevelFlag(flag *LevelFlag) LoggerOption {
+	return WithAtomicLevel(flag.AtomicLevel)
+}
+
+// WithCaller annotates entries with the file and line they were logged from.
+func WithCaller(enabled bool) LoggerOption {
+	return func(o *loggerOptions) {
+		o.caller = enabled
+	}
+}
+
+// WithStacktrace records a stack trace for entries at level and above.
+func WithStacktrace(level zapcore.Level) LoggerOption {
+	return func(o *loggerOptions) {
+		o.stacktrace = level
+	}
+}
+
+// WithSampling samples entries as described by cfg. Passing nil disables
+// sampling.
+func WithSampling(cfg *zap.SamplingConfig) LoggerOption {
+	return func(o *loggerOptions) {
+		o.sampling = cfg
+	}
+}
+
+// WithDedup collapses consecutive identical entries into a single one. See
+// NewDedupCore.
+func WithDedup(timeout time.Duration) LoggerOption {
+	return func(o *loggerOptions) {
+		o.dedup = timeout
+	}
+}
+
+// WithNameFilter writes entries only if filter lets them through, falling
+// back to the level of the logger for loggers none of its rules match.
+func WithNameFilter(filter *NameFilter) LoggerOption {
+	return func(o *loggerOptions) {
+		o.filter = filter
+	}
+}
+
+// WithOutput writes entries to ws instead of stderr.
+func WithOutput(ws zapcore.WriteSyncer) LoggerOption {
+	return func(o *loggerOptions) {
+		o.output = ws
+	}
+}
+
+// WithEncoderOptions configures the CLI encoder with opts.
+func WithEncoderOptions(opts ...Option) LoggerOption {
+	return func(o *loggerOptions) {
+		o.encoderOpts = append(o.encoderOpts, opts...)
+	}
+}
+
+// WithZapOptions applies opts to the logger after it's built.
+func WithZapOptions(opts ...zap.Option) LoggerOption {
+	return func(o *loggerOptions) {
+		o.zapOpts = append(o.zapOpts, opts...)
+	}
+}
+
+// NewCLI returns a logger suited to command line tools. It writes entries at
+// info level and above to stderr with the CLI encoder, without timestamps,
+// callers or stack traces.
+//
+// The returned function flushes buffered entries and should be called before
+// the program exits.
+func NewCLI(opts ...LoggerOption) (*zap.Logger, func()) {
+	o := &loggerOptions{
+		level:      zap.NewAtomicLevelAt(zapcore.InfoLevel),
+		stacktrace: zapcore.FatalLevel + 1,
+		output:     zapcore.Lock(os.Stderr),
+	}
+
+	cfg := zapcore.EncoderConfig{
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	return newLogger(cfg, o, opts)
+}
+
+// NewDevelopment returns a logger suited to development. It writes entries at
+// debug level and above to stderr with the CLI encoder, annotates them with
+// their caller and records stack traces for warnings and above. DPanic entries
+// panic.
+//
+// The returned function flushes buffered entries and should be called before
+// the program exits.
+func NewDevelopment(opts ...LoggerOption) (*zap.Logger, func()) {
+	o := &loggerOptions{
+		level:       zap.NewAtomicLevelAt(zapcore.DebugLevel),
+		caller:      true,
+		stacktrace:  zapcore.WarnLevel,
+		development: true,
+		output:      zapcore.Lock(os.Stderr),
+	}
+
+	return newLogger(zap.NewDevelopmentEncoderConfig(), o, opts)
+}
+
+func newLogger(cfg zapcore.EncoderConfig, o *loggerOptions, opts []LoggerOption) (*zap.Logger, func()) {
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var level zapcore.LevelEnabler = o.level
+	if o.filter != nil {
+		// The filter decides, so it mustn't be second-guessed.
+		level = zapcore.DebugLevel
+	}
+
+	var core zapcore.Core
+	core = NewCLICore(cfg, o.output, level, o.encoderOpts...)
+
+	if o.dedup > 0 {
+		core = NewDedupCore(core, o.dedup)
+	}
+
+	// The filter goes outside of the dedup core so entries it drops never
+	// end a run of repeats.
+	if o.filter != nil {
+		core = NewNameFilterCore(core, o.filter, o.level)
+	}
+
+	if o.sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, o.sampling.Initial, o.sampling.Thereafter)
+	}
+
+	zapOpts := []zap.Option{
+		zap.ErrorOutput(o.output),
+		zap.AddStacktrace(o.stacktrace),
+	}
+	if o.caller {
+		zapOpts = append(zapOpts, zap.AddCaller())
+	}
+	if o.development {
+		zapOpts = append(zapOpts, zap.Development())
+	}
+
+	logger := zap.New(core, append(zapOpts, o.zapOpts...)...)
+
+	return logger, func() {
+		// Syncing a terminal fails on some platforms; there's nothing the
+		// caller could do about it on the way out anyway.
+		_ = logger.Sync()
+	}
+}
+
+// LevelFlag is a flag.Value that sets the level of a logger. Since it wraps a
+// zap.AtomicLevel, setting it after the logger is built changes the level of
+// the running logger:
+//
+//	level := zappretty.NewLevelFlag(zapcore.InfoLevel)
+//	flag.Var(level, "log-level", "log level")
+//	flag.Parse()
+//
+//	logger, sync := zappretty.NewCLI(zappretty.WithLevelFlag(level))
+//	defer sync()
+type LevelFlag struct {
+	zap.AtomicLevel
+}
+
+// NewLevelFlag returns a LevelFlag set to level.
+func NewLevelFlag(level zapcore.Level) *LevelFlag {
+	return &LevelFlag{AtomicLevel: zap.NewAtomicLevelAt(level)}
+}
+
+// Set sets the level from its name, e.g. "debug" or "WARN".
+func (f *LevelFlag) Set(s string) error {
+	return f.UnmarshalText([]byte(s))
+}
+
+// String returns the name of the level.
+func (f *LevelFlag) String() string {
+	// The flag package calls String on the zero value to find the default.
+	if f == nil || f.AtomicLevel == (zap.AtomicLevel{}) {
+		return zapcore.InfoLevel.String()
+	}
+	return f.AtomicLevel.String()
+}
+
+// Type returns the type of the flag, as pflag expects.
+func (f *LevelFlag) Type() string {
+	return "level"
+}
@@ -0,0 +1,98 @@
+package zappretty
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewCLI(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	level := NewLevelFlag(zapcore.InfoLevel)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(level, "log-level", "log level")
+	assert.NoError(t, fs.Parse([]string{"-log-level", "warn"}))
+
+	var buf bytes.Buffer
+	logger, sync := NewCLI(WithLevelFlag(level), WithOutput(zapcore.AddSync(&buf)))
+	defer sync()
+
+	logger.Info("hidden")
+	logger.Warn("shown", zap.Int("count", 1))
+	assert.Equal(t, "WARN shown { \"count\": 1 }\n", buf.String())
+
+	// Changing the flag changes the level of the running logger.
+	buf.Reset()
+	assert.NoError(t, level.Set("debug"))
+	logger.Debug("now shown")
+	assert.Equal(t, "DEBUG now shown \n", buf.String())
+	assert.Equal(t, "debug", level.String())
+
+	assert.Error(t, level.Set("loud"))
+}
+
+func TestNewDevelopment(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	logger, sync := NewDevelopment(WithOutput(zapcore.AddSync(&buf)), WithStacktrace(zapcore.ErrorLevel))
+	defer sync()
+
+	logger.Warn("no stack")
+	assert.Contains(t, buf.String(), "logger_test.go:")
+	assert.NotContains(t, buf.String(), "TestNewDevelopment\n")
+
+	buf.Reset()
+	logger.Error("stack")
+	assert.Contains(t, buf.String(), "zappretty.TestNewDevelopment\n")
+
+	assert.Panics(t, func() { logger.DPanic("boom") })
+}
+
+func TestNewCLISampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger, sync := NewCLI(
+		WithOutput(zapcore.AddSync(&buf)),
+		WithSampling(&zap.SamplingConfig{Initial: 2, Thereafter: 100}),
+	)
+	defer sync()
+
+	for i := 0; i < 10; i++ {
+		logger.Info("repeated")
+	}
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("repeated")))
+}
+
+func TestNewCLIDedupAndNameFilter(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	filter, err := ParseNameFilter("db=off")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	logger, sync := NewCLI(
+		WithOutput(zapcore.AddSync(&buf)),
+		WithNameFilter(filter),
+		WithDedup(time.Hour),
+	)
+
+	logger.Named("http").Info("request")
+	logger.Named("db").Info("query")
+	logger.Named("http").Info("request")
+	logger.Named("db").Warn("slow query")
+	logger.Named("http").Info("request")
+	sync()
+
+	assert.Equal(t, "INFO  http request \nINFO  http request (repeated 3×) \n", buf.String())
+}
@@ -0,0 +1,50 @@
+package zappretty
+
+// Option configures the CLI encoder.
+type Option func(*options)
+
+type options struct {
+	theme            Theme
+	palette          *palette
+	timeLayout       string
+	dottedNamespaces bool
+	redaction        *RedactionPolicy
+	limits           *Limits
+	hyperlink        linkTemplate
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		theme:      DefaultTheme(),
+		timeLayout: timeFormat,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.palette = newPalette(o.theme)
+	return o
+}
+
+// WithTimeLayout formats entry timestamps with layout instead of
+// "2006-01-02 15:04:05 MST".
+func WithTimeLayout(layout string) Option {
+	return func(o *options) {
+		o.timeLayout = layout
+	}
+}
+
+// WithDottedNamespaces writes the fields of a namespace with the namespace as
+// a dotted prefix of their keys ("http.method": "GET") instead of nesting them
+// in a block ("http": {"method": "GET"}).
+func WithDottedNamespaces() Option {
+	return func(o *options) {
+		o.dottedNamespaces = true
+	}
+}
+
+// WithRedaction masks or hashes values matching policy before they're written.
+func WithRedaction(policy RedactionPolicy) Option {
+	return func(o *options) {
+		o.redaction = &policy
+	}
+}
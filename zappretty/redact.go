@@ -0,0 +1,181 @@
+package zappretty
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactAction decides what a redacted value is replaced with.
+type RedactAction int
+
+const (
+	// Mask replaces the value with the policy's mask.
+	Mask RedactAction = iota
+
+	// Hash replaces the value with a truncated SHA-256 of it. Equal secrets
+	// produce equal hashes so they can still be correlated across lines.
+	Hash
+)
+
+const defaultMask = "[REDACTED]"
+
+// RedactionPolicy describes which values the CLI encoder redacts before
+// writing them.
+type RedactionPolicy struct {
+	// Keys are field names whose values are always redacted. Matching is
+	// case-insensitive.
+	Keys []string
+
+	// KeyGlobs are path.Match patterns matched against lowercased field
+	// names, e.g. "*token*" or "*_secret".
+	KeyGlobs []string
+
+	// Values are matched against string values, including strings nested in
+	// objects, arrays and reflected values. Only the matching part of the
+	// string is redacted.
+	Values []*regexp.Regexp
+
+	// Action decides how values are redacted. Defaults to Mask.
+	Action RedactAction
+
+	// Mask is written in place of redacted values when Action is Mask.
+	// Defaults to "[REDACTED]".
+	Mask string
+}
+
+// DefaultRedactionPolicy returns a policy that masks the usual suspects:
+// passwords, secrets, tokens, API keys and authorization headers.
+func DefaultRedactionPolicy() RedactionPolicy {
+	return RedactionPolicy{
+		Keys:     []string{"authorization", "cookie", "set-cookie"},
+		KeyGlobs: []string{"*password*", "*passwd*", "*secret*", "*token*", "*api_key*", "*apikey*"},
+		Values: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)bearer\s+[a-z0-9._~+/-]+=*`),
+		},
+	}
+}
+
+// matchKey reports whether the value of key should be redacted.
+func (p *RedactionPolicy) matchKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, k := range p.Keys {
+		if strings.ToLower(k) == key {
+			return true
+		}
+	}
+
+	for _, glob := range p.KeyGlobs {
+		if ok, _ := path.Match(strings.ToLower(glob), key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redact returns the replacement for a value whose key matched. data is the
+// value's canonical encoding and is only used for hashing.
+func (p *RedactionPolicy) redact(data []byte) string {
+	if p.Action == Hash {
+		sum := sha256.Sum256(data)
+		return fmt.Sprintf("sha256:%x", sum[:8])
+	}
+
+	if p.Mask == "" {
+		return defaultMask
+	}
+
+	return p.Mask
+}
+
+// redactString replaces any part of s matching one of the value patterns.
+func (p *RedactionPolicy) redactString(s string) string {
+	for _, re := range p.Values {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			return p.redact([]byte(match))
+		})
+	}
+	return s
+}
+
+// redactValue returns the replacement for a field value whose key matched.
+func (p *RedactionPolicy) redactValue(value interface{}) string {
+	if p.Action != Hash {
+		return p.redact(nil)
+	}
+
+	var data []byte
+
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case zapcore.ObjectMarshaler:
+		m := zapcore.NewMapObjectEncoder()
+		_ = v.MarshalLogObject(m)
+		data, _ = json.Marshal(m.Fields)
+	case zapcore.ArrayMarshaler:
+		m := zapcore.NewMapObjectEncoder()
+		_ = m.AddArray("", v)
+		data, _ = json.Marshal(m.Fields[""])
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			data = []byte(fmt.Sprint(v))
+		}
+	}
+
+	return p.redact(data)
+}
+
+// redactJSON returns v, a value decoded by DecodeJSON, with the values of
+// members whose key matches and the matching parts of strings redacted the
+// way the encoder redacts fields.
+func (p *RedactionPolicy) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case JSONObject:
+		obj := make(JSONObject, len(v))
+		for i, m := range v {
+			obj[i].Key = m.Key
+			if p.matchKey(m.Key) {
+				obj[i].Value = p.redactValue(m.Value)
+			} else {
+				obj[i].Value = p.redactJSON(m.Value)
+			}
+		}
+		return obj
+	case JSONArray:
+		arr := make(JSONArray, len(v))
+		for i, elem := range v {
+			arr[i] = p.redactJSON(elem)
+		}
+		return arr
+	case string:
+		return p.redactString(v)
+	}
+	return v
+}
+
+// redactIndentedJSON redacts data, an indented JSON document, with redactJSON
+// and indents it again.
+func (p *RedactionPolicy) redactIndentedJSON(data []byte) []byte {
+	v, err := DecodeJSON(data)
+	if err == nil {
+		var redacted []byte
+		if redacted, err = json.MarshalIndent(p.redactJSON(v), "", "  "); err == nil {
+			return redacted
+		}
+	}
+
+	// Leave nothing out rather than risk leaking a secret.
+	return []byte(strconv.Quote(p.redact(data)))
+}
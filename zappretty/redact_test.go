@@ -0,0 +1,111 @@
+package zappretty
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Note     string `json:"note"`
+}
+
+func (c credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", c.User)
+	enc.AddString("password", c.Password)
+	enc.AddString("note", c.Note)
+	return nil
+}
+
+func TestRedaction(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	creds := credentials{User: "bob", Password: "hunter2", Note: "token is sk-abc123"}
+	policy := RedactionPolicy{
+		Keys:     []string{"Password"},
+		KeyGlobs: []string{"*token*"},
+		Values:   []*regexp.Regexp{regexp.MustCompile(`sk-[a-z0-9]+`)},
+	}
+
+	testcases := []struct {
+		name    string
+		field   zapcore.Field
+		want    string
+		notWant string
+	}{
+		{
+			name:    "key",
+			field:   zap.String("password", "hunter2"),
+			want:    `"password": "[REDACTED]"`,
+			notWant: "hunter2",
+		},
+		{
+			name:    "key glob",
+			field:   zap.Int("refresh_token", 42),
+			want:    `"refresh_token": "[REDACTED]"`,
+			notWant: "42",
+		},
+		{
+			name:    "value regex",
+			field:   zap.String("note", "key sk-abc123 leaked"),
+			want:    `"note": "key [REDACTED] leaked"`,
+			notWant: "sk-abc123",
+		},
+		{
+			name:    "array",
+			field:   zap.Strings("notes", []string{"sk-abc123"}),
+			want:    `"notes": ["[REDACTED]"]`,
+			notWant: "sk-abc123",
+		},
+		{
+			name:    "object",
+			field:   zap.Object("creds", creds),
+			want:    `"password": "[REDACTED]"`,
+			notWant: "hunter2",
+		},
+		{
+			name:    "reflected",
+			field:   zap.Reflect("creds", creds),
+			want:    `"password": "[REDACTED]", "note": "token is [REDACTED]"`,
+			notWant: "hunter2",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithRedaction(policy))
+
+			out, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch}, []zapcore.Field{tc.field})
+			assert.NoError(t, err)
+			assert.Contains(t, out.String(), tc.want)
+			assert.NotContains(t, out.String(), tc.notWant)
+		})
+	}
+}
+
+func TestRedactionHash(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	policy := DefaultRedactionPolicy()
+	policy.Action = Hash
+
+	encoder := NewCLIEncoder(EncoderTestEncoderConfig(), WithRedaction(policy))
+
+	first, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch}, []zapcore.Field{zap.String("api_key", "hunter2")})
+	assert.NoError(t, err)
+
+	second, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch}, []zapcore.Field{zap.String("api_key", "hunter2")})
+	assert.NoError(t, err)
+
+	assert.NotContains(t, first.String(), "hunter2")
+	assert.Contains(t, first.String(), `"api_key": "sha256:`)
+	assert.Equal(t, first.String(), second.String())
+}
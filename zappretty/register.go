@@ -0,0 +1,150 @@
+package zappretty
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string][]Option{}
+)
+
+// Register registers the CLI encoder with zap under name so it can be used by
+// setting zap.Config.Encoding to name. The encoder is built from the
+// EncoderConfig zap passes along with opts applied on top.
+//
+// Several variants can be registered under different names. Registering a
+// name again replaces its options.
+//
+// zap builds the core of such loggers with zapcore.NewCore, so errors added
+// to them with With are written without their fields and hints. Build the
+// core with NewCLICore to get those too.
+func Register(name string, opts ...Option) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; !ok {
+		err := zap.RegisterEncoder(name, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			registryMu.Lock()
+			opts := registry[name]
+			registryMu.Unlock()
+
+			return NewCLIEncoder(cfg, opts...), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	registry[name] = opts
+	return nil
+}
+
+// EncoderOptions are the options of the CLI encoder in a form that can be
+// decoded from JSON or YAML.
+type EncoderOptions struct {
+	// Theme overrides parts of the default theme. Keys are the names of
+	// the Theme fields or of levels, values are styles as understood by
+	// ParseStyle, e.g. {"key": "cyan bold", "error": "bg:red white"}.
+	Theme map[string]string `json:"theme" yaml:"theme"`
+
+	// TimeLayout is the layout entry timestamps are formatted with.
+	TimeLayout string `json:"timeLayout" yaml:"timeLayout"`
+
+	// DottedNamespaces writes namespaces as dotted key prefixes.
+	DottedNamespaces bool `json:"dottedNamespaces" yaml:"dottedNamespaces"`
+
+	// Limits truncates values that exceed them.
+	Limits *Limits `json:"limits" yaml:"limits"`
+
+	// Hyperlinks renders callers as hyperlinks built from this template,
+	// e.g. "vscode://file{file}:{line}". See WithHyperlinks.
+	Hyperlinks string `json:"hyperlinks" yaml:"hyperlinks"`
+}
+
+// Options converts o into the options it stands for.
+func (o EncoderOptions) Options() ([]Option, error) {
+	var opts []Option
+
+	if len(o.Theme) > 0 {
+		theme := DefaultTheme()
+
+		// Sort the parts so errors are reported deterministically.
+		parts := make([]string, 0, len(o.Theme))
+		for part := range o.Theme {
+			parts = append(parts, part)
+		}
+		sort.Strings(parts)
+
+		for _, part := range parts {
+			style, err := ParseStyle(o.Theme[part])
+			if err != nil {
+				return nil, fmt.Errorf("theme %s: %w", part, err)
+			}
+
+			if err := theme.set(part, style); err != nil {
+				return nil, err
+			}
+		}
+
+		opts = append(opts, WithTheme(theme))
+	}
+
+	if o.TimeLayout != "" {
+		opts = append(opts, WithTimeLayout(o.TimeLayout))
+	}
+
+	if o.DottedNamespaces {
+		opts = append(opts, WithDottedNamespaces())
+	}
+
+	if o.Limits != nil {
+		opts = append(opts, WithLimits(*o.Limits))
+	}
+
+	if o.Hyperlinks != "" {
+		opts = append(opts, WithHyperlinks(o.Hyperlinks))
+	}
+
+	return opts, nil
+}
+
+// Config is a zap.Config with a section for the options of the CLI encoder.
+// It decodes from the same JSON or YAML as zap.Config does:
+//
+//	{
+//	  "level": "info",
+//	  "encoding": "cli",
+//	  "outputPaths": ["stderr"],
+//	  "encoderConfig": {"messageKey": "msg", "levelKey": "level", "timeKey": "ts"},
+//	  "cliEncoder": {"limits": {"maxStringLength": 256}}
+//	}
+type Config struct {
+	zap.Config `yaml:",inline"`
+
+	// CLIEncoder holds the options of the CLI encoder.
+	CLIEncoder EncoderOptions `json:"cliEncoder" yaml:"cliEncoder"`
+}
+
+// Build registers the CLI encoder under c.Encoding with the options from the
+// cliEncoder section and builds a logger from the zap config. The built-in
+// "json" and "console" encodings are left alone.
+func (c Config) Build(opts ...zap.Option) (*zap.Logger, error) {
+	if c.Encoding != "json" && c.Encoding != "console" {
+		encOpts, err := c.CLIEncoder.Options()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Register(c.Encoding, encOpts...); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.Config.Build(opts...)
+}
@@ -0,0 +1,66 @@
+package zappretty
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRegister(t *testing.T) {
+	assert.NoError(t, Register("cli-test", WithTimeLayout("15:04")))
+	assert.NoError(t, Register("cli-test", WithDottedNamespaces()), "registering a name again should replace its options")
+	assert.Error(t, Register("json"), "built-in encoders can't be replaced")
+}
+
+func TestConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+
+	data := `{
+		"level": "info",
+		"encoding": "cli-config",
+		"outputPaths": [` + quote(path) + `],
+		"encoderConfig": {"messageKey": "msg", "levelKey": "level", "levelEncoder": "capital"},
+		"cliEncoder": {
+			"theme": {"key": "cyan", "error": "bg:red white"},
+			"dottedNamespaces": true,
+			"limits": {"maxStringLength": 3}
+		}
+	}`
+
+	var cfg Config
+	require.NoError(t, json.Unmarshal([]byte(data), &cfg))
+
+	logger, err := cfg.Build()
+	require.NoError(t, err)
+
+	logger.Debug("hidden")
+	logger.Info("hello", zap.Namespace("http"), zap.String("method", "GET /"))
+	require.NoError(t, logger.Sync())
+
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "INFO  hello { \"http.method\": \"GET…(+2 bytes)\" }\n", stripEscapes(string(out)))
+	assert.Contains(t, string(out), "\x1b[36m\"http.method\":\x1b[0m")
+}
+
+func TestConfigInvalidTheme(t *testing.T) {
+	cfg := Config{
+		Config:     zap.NewProductionConfig(),
+		CLIEncoder: EncoderOptions{Theme: map[string]string{"key": "sparkly"}},
+	}
+	cfg.Encoding = "cli-invalid"
+
+	_, err := cfg.Build()
+	assert.EqualError(t, err, `theme key: unknown style attribute "sparkly"`)
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
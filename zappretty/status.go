@@ -0,0 +1,153 @@
+package zappretty
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	// clearLine moves the cursor to the start of the line and erases it.
+	clearLine = "\r\x1b[2K"
+)
+
+// StatusWriter writes log entries above a status line pinned to the bottom of
+// the terminal, such as a progress bar or spinner. The status line is erased
+// before each entry is written and redrawn after it, so entries never tear
+// through it:
+//
+//	status := zappretty.NewStatusWriter(os.Stderr)
+//	logger, sync := zappretty.NewCLI(zappretty.WithOutput(status))
+//	defer sync()
+//
+//	status.SetStatus("downloading 3/10")
+//	logger.Info("fetched", zap.String("file", "a.tar.gz"))
+//	status.ClearStatus()
+//
+// When the output isn't a terminal, entries are written as is and the status
+// line is never drawn.
+//
+// A StatusWriter is safe for concurrent use.
+type StatusWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+	tty bool
+
+	status string
+	drawn  bool
+
+	// partial is set when the last write didn't end a line, in which case
+	// the status line waits until the line is finished.
+	partial bool
+}
+
+// NewStatusWriter returns a StatusWriter writing to out.
+func NewStatusWriter(out io.Writer) *StatusWriter {
+	w := &StatusWriter{out: out}
+
+	if f, ok := out.(interface{ Fd() uintptr }); ok {
+		w.tty = isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+	}
+
+	return w
+}
+
+// Write writes p above the status line.
+func (w *StatusWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.tty {
+		return w.out.Write(p)
+	}
+
+	if err := w.erase(); err != nil {
+		return 0, err
+	}
+
+	n, err := w.out.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	w.partial = len(p) > 0 && p[len(p)-1] != '\n'
+
+	return n, w.draw()
+}
+
+// Sync flushes the output if it can be synced.
+func (w *StatusWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if s, ok := w.out.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// SetStatus replaces the text of the status line. Line breaks are replaced
+// with spaces since the status has to fit on a single line.
+func (w *StatusWriter) SetStatus(status string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.status = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, status)
+
+	if !w.tty || w.partial {
+		return nil
+	}
+
+	if err := w.erase(); err != nil {
+		return err
+	}
+	return w.draw()
+}
+
+// ClearStatus erases the status line.
+func (w *StatusWriter) ClearStatus() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.status = ""
+
+	if !w.tty {
+		return nil
+	}
+	return w.erase()
+}
+
+// Status returns the text of the status line.
+func (w *StatusWriter) Status() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status
+}
+
+func (w *StatusWriter) erase() error {
+	if !w.drawn {
+		return nil
+	}
+
+	w.drawn = false
+	_, err := io.WriteString(w.out, clearLine)
+	return err
+}
+
+func (w *StatusWriter) draw() error {
+	if w.status == "" || w.partial {
+		return nil
+	}
+
+	w.drawn = true
+	_, err := io.WriteString(w.out, w.status)
+	return err
+}
@@ -0,0 +1,71 @@
+package zappretty
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStatusWriter(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	status := NewStatusWriter(&buf)
+	status.tty = true
+
+	logger, sync := NewCLI(WithOutput(status))
+	defer sync()
+
+	assert.NoError(t, status.SetStatus("step 1/2"))
+	logger.Info("first")
+	assert.NoError(t, status.SetStatus("step\n2/2"))
+	logger.Info("second")
+	assert.NoError(t, status.ClearStatus())
+	logger.Info("done")
+
+	want := "step 1/2" +
+		clearLine + "INFO  first \n" + "step 1/2" +
+		clearLine + "step 2/2" +
+		clearLine + "INFO  second \n" + "step 2/2" +
+		clearLine +
+		"INFO  done \n"
+
+	assert.Equal(t, want, buf.String())
+}
+
+func TestStatusWriterPartialLine(t *testing.T) {
+	var buf bytes.Buffer
+	status := NewStatusWriter(&buf)
+	status.tty = true
+
+	assert.NoError(t, status.SetStatus("working"))
+
+	_, err := status.Write([]byte("half"))
+	assert.NoError(t, err)
+	assert.NoError(t, status.SetStatus("still working"))
+
+	_, err = status.Write([]byte(" line\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "working"+clearLine+"half line\nstill working", buf.String())
+}
+
+func TestStatusWriterNotTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	status := NewStatusWriter(&buf)
+
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), status, zapcore.InfoLevel))
+
+	assert.NoError(t, status.SetStatus("hidden"))
+	logger.Info("shown")
+	assert.NoError(t, status.ClearStatus())
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.NotContains(t, buf.String(), clearLine)
+	assert.Contains(t, buf.String(), `"msg":"shown"`)
+}
@@ -0,0 +1,118 @@
+package zappretty
+
+import (
+	"errors"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/logrotate"
+)
+
+// TeeConfig configures a logger that writes entries to two sinks at once:
+// readable output on the terminal with the CLI encoder and JSON to a file for
+// later analysis.
+type TeeConfig struct {
+	// ConsoleLevel is the level entries need to be written to Console.
+	// Defaults to info.
+	ConsoleLevel zapcore.LevelEnabler
+
+	// Console is where the CLI encoder writes to. Defaults to stderr.
+	Console zapcore.WriteSyncer
+
+	// EncoderOptions configure the CLI encoder.
+	EncoderOptions []Option
+
+	// FileLevel is the level entries need to be written to File. Defaults
+	// to debug.
+	FileLevel zapcore.LevelEnabler
+
+	// File is where the JSON encoder writes to. If it's nil, entries are
+	// appended to the file at FilePath instead.
+	File zapcore.WriteSyncer
+
+	// FilePath is the file the JSON encoder appends to when File is nil.
+	// Missing directories are created.
+	FilePath string
+
+	// Rotation configures how the file at FilePath is rotated, e.g.
+	// logrotate.WithMaxSize(100). It's never rotated by default.
+	Rotation []logrotate.Option
+
+	// Fields are added to the entries of both sinks.
+	Fields []zap.Field
+}
+
+// Core builds the core writing to both sinks. The returned function flushes
+// both sinks and closes the file opened for FilePath, if any.
+func (c TeeConfig) Core() (zapcore.Core, func() error, error) {
+	consoleLevel := c.ConsoleLevel
+	if consoleLevel == nil {
+		consoleLevel = zapcore.InfoLevel
+	}
+
+	fileLevel := c.FileLevel
+	if fileLevel == nil {
+		fileLevel = zapcore.DebugLevel
+	}
+
+	console := c.Console
+	if console == nil {
+		console = zapcore.Lock(os.Stderr)
+	}
+
+	file := c.File
+	closeFile := func() error { return nil }
+
+	if file == nil {
+		if c.FilePath == "" {
+			return nil, nil, errors.New("tee: either File or FilePath must be set")
+		}
+
+		w, err := logrotate.New(c.FilePath, c.Rotation...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		file = w
+		closeFile = w.Close
+	}
+
+	fileConfig := zap.NewProductionEncoderConfig()
+	fileConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	core := zapcore.NewTee(
+		NewCLICore(zap.NewDevelopmentEncoderConfig(), console, consoleLevel, c.EncoderOptions...),
+		zapcore.NewCore(zapcore.NewJSONEncoder(fileConfig), file, fileLevel),
+	)
+
+	if len(c.Fields) > 0 {
+		core = core.With(c.Fields)
+	}
+
+	cleanup := func() error {
+		// Syncing a terminal fails on some platforms, so only the file's
+		// errors are reported.
+		_ = console.Sync()
+
+		err := file.Sync()
+		if cerr := closeFile(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	return core, cleanup, nil
+}
+
+// Build builds a logger writing to both sinks with opts applied. The returned
+// function flushes both sinks and should be called before the program exits.
+func (c TeeConfig) Build(opts ...zap.Option) (*zap.Logger, func(), error) {
+	core, cleanup, err := c.Core()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return zap.New(core, opts...), func() { _ = cleanup() }, nil
+}
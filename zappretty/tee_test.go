@@ -0,0 +1,57 @@
+package zappretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTee(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var console bytes.Buffer
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	logger, sync, err := TeeConfig{
+		ConsoleLevel: zapcore.WarnLevel,
+		Console:      zapcore.AddSync(&console),
+		FilePath:     path,
+		Fields:       []zap.Field{zap.String("service", "api")},
+	}.Build()
+	require.NoError(t, err)
+
+	logger.Debug("debug only in file")
+	logger.Warn("everywhere", zap.Int("attempt", 2))
+	sync()
+
+	assert.NotContains(t, console.String(), "debug only in file")
+	assert.Contains(t, console.String(), "everywhere")
+	assert.Contains(t, console.String(), `"service": "api", "attempt": 2`)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "everywhere", entry["msg"])
+	assert.Equal(t, "api", entry["service"])
+	assert.Equal(t, float64(2), entry["attempt"])
+}
+
+func TestTeeRequiresFile(t *testing.T) {
+	_, _, err := TeeConfig{}.Build()
+	assert.Error(t, err)
+}
@@ -0,0 +1,282 @@
+package zappretty
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"go.uber.org/zap/zapcore"
+)
+
+// Style is the set of attributes used to color a part of the output.
+type Style []color.Attribute
+
+// Theme decides how each part of an entry is colored.
+type Theme struct {
+	Timestamp   Style
+	Levels      map[zapcore.Level]Style
+	LoggerName  Style
+	Caller      Style
+	Message     Style
+	Key         Style
+	Punctuation Style
+	String      Style
+	Number      Style
+	Bool        Style
+	Null        Style
+	Duration    Style
+	Time        Style
+	Bytes       Style
+	Elision     Style
+	Stacktrace  Style
+	DiffHeader  Style
+	DiffHunk    Style
+	DiffAdded   Style
+	DiffRemoved Style
+	Hint        Style
+}
+
+// DefaultTheme returns the theme used unless WithTheme is given.
+func DefaultTheme() Theme {
+	return Theme{
+		Timestamp: Style{color.FgWhite},
+		Levels: map[zapcore.Level]Style{
+			zapcore.DebugLevel:  {color.FgBlue},
+			zapcore.InfoLevel:   {color.FgGreen},
+			zapcore.WarnLevel:   {color.FgYellow},
+			zapcore.ErrorLevel:  {color.FgRed},
+			zapcore.DPanicLevel: {color.FgRed},
+			zapcore.PanicLevel:  {color.FgRed},
+			zapcore.FatalLevel:  {color.FgRed},
+		},
+		LoggerName:  Style{color.FgHiBlack},
+		Caller:      Style{color.FgHiBlack},
+		Message:     Style{color.FgHiWhite},
+		Key:         Style{color.FgBlue, color.Bold},
+		Punctuation: Style{color.FgWhite, color.Bold},
+		String:      Style{color.FgGreen},
+		Number:      Style{color.FgCyan},
+		Bool:        Style{color.FgYellow},
+		Null:        Style{color.FgHiBlack},
+		Duration:    Style{color.FgMagenta},
+		Time:        Style{color.FgHiCyan},
+		Bytes:       Style{color.FgHiYellow},
+		Elision:     Style{color.FgHiBlack},
+		Stacktrace:  Style{color.FgWhite},
+		DiffHeader:  Style{color.Bold},
+		DiffHunk:    Style{color.FgCyan},
+		DiffAdded:   Style{color.FgGreen},
+		DiffRemoved: Style{color.FgRed},
+		Hint:        Style{color.FgYellow, color.Bold},
+	}
+}
+
+var attributeNames = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+	"black":     color.FgBlack,
+	"red":       color.FgRed,
+	"green":     color.FgGreen,
+	"yellow":    color.FgYellow,
+	"blue":      color.FgBlue,
+	"magenta":   color.FgMagenta,
+	"cyan":      color.FgCyan,
+	"white":     color.FgWhite,
+	"hiblack":   color.FgHiBlack,
+	"hired":     color.FgHiRed,
+	"higreen":   color.FgHiGreen,
+	"hiyellow":  color.FgHiYellow,
+	"hiblue":    color.FgHiBlue,
+	"himagenta": color.FgHiMagenta,
+	"hicyan":    color.FgHiCyan,
+	"hiwhite":   color.FgHiWhite,
+}
+
+// ParseStyle parses a style from a list of attribute names separated by
+// spaces, e.g. "blue bold" or "bg:red hiwhite". Colors are foreground colors
+// unless they're prefixed with "bg:".
+func ParseStyle(s string) (Style, error) {
+	var style Style
+
+	for _, name := range strings.Fields(strings.ToLower(s)) {
+		bg := strings.HasPrefix(name, "bg:")
+
+		attr, ok := attributeNames[strings.TrimPrefix(name, "bg:")]
+		if !ok || (bg && attr < color.FgBlack) {
+			return nil, fmt.Errorf("unknown style attribute %q", name)
+		}
+
+		if bg {
+			// Background colors are offset by 10 from the foreground ones.
+			attr += color.BgBlack - color.FgBlack
+		}
+
+		style = append(style, attr)
+	}
+
+	return style, nil
+}
+
+// set replaces the style of the part of an entry called name.
+func (t *Theme) set(name string, style Style) error {
+	if level, err := zapcore.ParseLevel(name); err == nil {
+		levels := make(map[zapcore.Level]Style, len(t.Levels)+1)
+		for l, s := range t.Levels {
+			levels[l] = s
+		}
+		levels[level] = style
+		t.Levels = levels
+		return nil
+	}
+
+	parts := map[string]*Style{
+		"timestamp":   &t.Timestamp,
+		"loggername":  &t.LoggerName,
+		"caller":      &t.Caller,
+		"message":     &t.Message,
+		"key":         &t.Key,
+		"punctuation": &t.Punctuation,
+		"string":      &t.String,
+		"number":      &t.Number,
+		"bool":        &t.Bool,
+		"null":        &t.Null,
+		"duration":    &t.Duration,
+		"time":        &t.Time,
+		"bytes":       &t.Bytes,
+		"elision":     &t.Elision,
+		"stacktrace":  &t.Stacktrace,
+		"diffheader":  &t.DiffHeader,
+		"diffhunk":    &t.DiffHunk,
+		"diffadded":   &t.DiffAdded,
+		"diffremoved": &t.DiffRemoved,
+		"hint":        &t.Hint,
+	}
+
+	part, ok := parts[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown theme part %q", name)
+	}
+
+	*part = style
+	return nil
+}
+
+// WithTheme colors entries using theme instead of the default one.
+func WithTheme(theme Theme) Option {
+	return func(o *options) {
+		o.theme = theme
+	}
+}
+
+// sgr holds the escape sequences that turn a style on and off. They're
+// computed once per encoder so writing a colored value doesn't allocate.
+type sgr struct {
+	on  string
+	off string
+}
+
+// resetAttributes are the attributes that turn off a single attribute
+// instead of resetting all of them. It mirrors what the color package does so
+// the output is the same as color.New(style...).Sprint.
+var resetAttributes = map[color.Attribute]color.Attribute{
+	color.Bold:         color.ResetBold,
+	color.Faint:        color.ResetBold,
+	color.Italic:       color.ResetItalic,
+	color.Underline:    color.ResetUnderline,
+	color.BlinkSlow:    color.ResetBlinking,
+	color.BlinkRapid:   color.ResetBlinking,
+	color.ReverseVideo: color.ResetReversed,
+	color.Concealed:    color.ResetConcealed,
+	color.CrossedOut:   color.ResetCrossedOut,
+}
+
+func newSGR(style Style) sgr {
+	if len(style) == 0 {
+		return sgr{}
+	}
+
+	on := make([]string, len(style))
+	off := make([]string, len(style))
+
+	for i, attr := range style {
+		on[i] = strconv.Itoa(int(attr))
+
+		reset, ok := resetAttributes[attr]
+		if !ok {
+			reset = color.Reset
+		}
+		off[i] = strconv.Itoa(int(reset))
+	}
+
+	return sgr{
+		on:  "\x1b[" + strings.Join(on, ";") + "m",
+		off: "\x1b[" + strings.Join(off, ";") + "m",
+	}
+}
+
+// palette is a compiled Theme.
+type palette struct {
+	timestamp   sgr
+	levels      [zapcore.FatalLevel - zapcore.DebugLevel + 1]sgr
+	loggerName  sgr
+	caller      sgr
+	message     sgr
+	key         sgr
+	punctuation sgr
+	str         sgr
+	number      sgr
+	boolean     sgr
+	null        sgr
+	duration    sgr
+	time        sgr
+	bytes       sgr
+	elision     sgr
+	stacktrace  sgr
+	diffHeader  sgr
+	diffHunk    sgr
+	diffAdded   sgr
+	diffRemoved sgr
+	hint        sgr
+}
+
+func newPalette(theme Theme) *palette {
+	p := &palette{
+		timestamp:   newSGR(theme.Timestamp),
+		loggerName:  newSGR(theme.LoggerName),
+		caller:      newSGR(theme.Caller),
+		message:     newSGR(theme.Message),
+		key:         newSGR(theme.Key),
+		punctuation: newSGR(theme.Punctuation),
+		str:         newSGR(theme.String),
+		number:      newSGR(theme.Number),
+		boolean:     newSGR(theme.Bool),
+		null:        newSGR(theme.Null),
+		duration:    newSGR(theme.Duration),
+		time:        newSGR(theme.Time),
+		bytes:       newSGR(theme.Bytes),
+		elision:     newSGR(theme.Elision),
+		stacktrace:  newSGR(theme.Stacktrace),
+		diffHeader:  newSGR(theme.DiffHeader),
+		diffHunk:    newSGR(theme.DiffHunk),
+		diffAdded:   newSGR(theme.DiffAdded),
+		diffRemoved: newSGR(theme.DiffRemoved),
+		hint:        newSGR(theme.Hint),
+	}
+
+	for level := zapcore.DebugLevel; level <= zapcore.FatalLevel; level++ {
+		p.levels[level-zapcore.DebugLevel] = newSGR(theme.Levels[level])
+	}
+
+	return p
+}
+
+// level returns the escape sequences of level.
+func (p *palette) level(level zapcore.Level) sgr {
+	if level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		return sgr{}
+	}
+	return p.levels[level-zapcore.DebugLevel]
+}
@@ -5,16 +5,16 @@
 package zappretty
 
 import (
-	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"math"
+	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/fatih/color"
-	"go.uber.org/zap"
 	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/zapcore"
 )
@@ -30,35 +30,46 @@ var (
 	cliPool          = sync.Pool{New: func() interface{} {
 		return &cliEncoder{}
 	}}
-	bufPool    = buffer.NewPool()
-	levelColor = map[zapcore.Level]color.Attribute{
-		zapcore.DebugLevel:  color.FgBlue,
-		zapcore.InfoLevel:   color.FgGreen,
-		zapcore.WarnLevel:   color.FgYellow,
-		zapcore.ErrorLevel:  color.FgRed,
-		zapcore.DPanicLevel: color.FgRed,
-		zapcore.PanicLevel:  color.FgRed,
-		zapcore.FatalLevel:  color.FgRed,
-	}
+	bufPool = buffer.NewPool()
 )
 
-func Register(cfg zapcore.EncoderConfig) {
-	_ = zap.RegisterEncoder("cli", func(_ zapcore.EncoderConfig) (zapcore.Encoder, error) {
-		return NewCLIEncoder(cfg), nil
-	})
-}
-
 type cliEncoder struct {
 	*zapcore.EncoderConfig
+	opts           *options
 	buf            *buffer.Buffer
 	openNamespaces int
 
+	// first is set when the next element is the first one of an object or
+	// array, or the value of a key, and so doesn't need a separator.
+	first bool
+
+	// namespaces are the open namespaces when they're written as dotted key
+	// prefixes.
+	namespaces []string
+
+	// valueStyle overrides the style of primitive values while a duration or
+	// time is passed to the user-supplied encoders.
+	valueStyle *sgr
+
+	// depth is the nesting depth of the object or array being encoded.
+	depth int
+
+	// unlimited is set when the entry being encoded is exempt from limits.
+	unlimited bool
+
+	// diffs are written below the entry once it's complete.
+	diffs []pendingDiff
+
+	// hints are the hints of the errors logged with the entry, written
+	// below it.
+	hints []string
+
 	// for encoding generic values by reflection
 	reflectBuf *buffer.Buffer
 	reflectEnc zapcore.ReflectedEncoder
 }
 
-func NewCLIEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+func NewCLIEncoder(cfg zapcore.EncoderConfig, opts ...Option) zapcore.Encoder {
 	cfg.LineEnding = zapcore.DefaultLineEnding
 
 	if cfg.NewReflectedEncoder == nil {
@@ -67,6 +78,7 @@ func NewCLIEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
 
 	encoder := &cliEncoder{
 		EncoderConfig: &cfg,
+		opts:          newOptions(opts...),
 		buf:           bufPool.Get(),
 	}
 
@@ -80,12 +92,19 @@ func newCLIEncoder() *cliEncoder {
 func (enc *cliEncoder) Clone() zapcore.Encoder {
 	clone := enc.clone()
 	clone.buf.Write(enc.buf.Bytes())
+	clone.first = enc.first
+	clone.diffs = append(clone.diffs, enc.diffs...)
+	clone.hints = append(clone.hints, enc.hints...)
 	return clone
 }
 
 func (enc *cliEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
 	final := enc.clone()
 
+	if l := enc.opts.limits; l != nil && l.DisableForDebug && entry.Level == zapcore.DebugLevel {
+		final.unlimited = true
+	}
+
 	if final.TimeKey != "" {
 		final.encodeTimestamp(entry.Time)
 	}
@@ -106,26 +125,41 @@ func (enc *cliEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field)
 		final.encodeMessage(entry.Message)
 	}
 
+	hasFields := enc.buf.Len() > 0 || len(fields) > 0
+
+	if hasFields {
+		final.paint(final.colors().punctuation, "{")
+		final.buf.AppendByte(' ')
+		final.first = true
+	}
+
+	// Add fields from the logger's context before the ones of the entry.
 	if enc.buf.Len() > 0 {
-		final.addElementSeparator()
 		final.buf.Write(enc.buf.Bytes())
+		final.first = enc.first
+		final.diffs = append(final.diffs, enc.diffs...)
+		final.hints = append(final.hints, enc.hints...)
 	}
 
-	if len(fields) > 0 {
-		final.buf.AppendString(colorize('{', color.FgWhite, color.Bold))
+	final.addFields(fields)
+
+	final.closeOpenNamespaces()
+
+	if hasFields {
 		final.buf.AppendByte(' ')
+		final.paint(final.colors().punctuation, "}")
 	}
 
-	// Add fields.
-	for i := range fields {
-		fields[i].AddTo(final)
+	if l := final.limits(); l != nil && l.MaxLineWidth > 0 {
+		truncateLine(final.buf, l.MaxLineWidth, final.colors().elision)
 	}
 
-	final.closeOpenNamespaces()
+	final.writeHints()
+	final.writeDiffs()
 
-	if len(fields) > 0 {
-		final.buf.AppendByte(' ')
-		final.buf.AppendString(colorize('}', color.FgWhite, color.Bold))
+	if entry.Stack != "" && final.StacktraceKey != "" {
+		final.buf.AppendByte('\n')
+		final.paint(final.colors().stacktrace, entry.Stack)
 	}
 
 	final.buf.AppendString(final.LineEnding)
@@ -135,152 +169,219 @@ func (enc *cliEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field)
 	return buf, nil
 }
 
+// addFields adds fields to the encoder. Errors logged with zap.Error are
+// added with addError, since zap only passes their message and details on.
+func (enc *cliEncoder) addFields(fields []zapcore.Field) {
+	for i := range fields {
+		if err, ok := fields[i].Interface.(error); ok && fields[i].Type == zapcore.ErrorType {
+			enc.addError(fields[i], err)
+			continue
+		}
+		fields[i].AddTo(enc)
+	}
+}
+
 func (enc *cliEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, marshaler)
+		return nil
+	}
+	if d, ok := marshaler.(*diff); ok {
+		return enc.addDiff(key, d)
+	}
 	enc.addKey(key)
 	return enc.AppendArray(marshaler)
 }
 
 func (enc *cliEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, marshaler)
+		return nil
+	}
+	if err, ok := marshaler.(error); ok {
+		enc.addHints(err)
+	}
 	enc.addKey(key)
 	return enc.AppendObject(marshaler)
 }
 
 func (enc *cliEncoder) AddBinary(key string, value []byte) {
-	enc.AddString(key, base64.StdEncoding.EncodeToString(value))
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
+	enc.addKey(key)
+	enc.appendBinary(value)
 }
 
 func (enc *cliEncoder) AddByteString(key string, value []byte) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendByteString(value)
 }
 
 func (enc *cliEncoder) AddBool(key string, value bool) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendBool(value)
 }
 
 func (enc *cliEncoder) AddComplex128(key string, value complex128) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, fmt.Sprint(value))
+		return
+	}
 	enc.addKey(key)
 	enc.appendComplex(value, 64)
 }
 
 func (enc *cliEncoder) AddComplex64(key string, value complex64) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, fmt.Sprint(value))
+		return
+	}
 	enc.addKey(key)
 	enc.appendComplex(complex128(value), 32)
 }
 
 func (enc *cliEncoder) AddDuration(key string, value time.Duration) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendDuration(value)
 }
 
 func (enc *cliEncoder) AddFloat64(key string, value float64) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendFloat64(value)
 }
 
 func (enc *cliEncoder) AddFloat32(key string, value float32) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendFloat32(value)
 }
 
-func (enc *cliEncoder) AddInt(key string, value int) {
-	enc.addKey(key)
-	enc.AddInt64(key, int64(value))
-}
+func (enc *cliEncoder) AddInt(key string, value int)     { enc.AddInt64(key, int64(value)) }
+func (enc *cliEncoder) AddInt32(key string, value int32) { enc.AddInt64(key, int64(value)) }
+func (enc *cliEncoder) AddInt16(key string, value int16) { enc.AddInt64(key, int64(value)) }
+func (enc *cliEncoder) AddInt8(key string, value int8)   { enc.AddInt64(key, int64(value)) }
 
 func (enc *cliEncoder) AddInt64(key string, value int64) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendInt64(value)
 }
 
-func (enc *cliEncoder) AddInt32(key string, value int32) {
-	enc.addKey(key)
-	enc.AddInt64(key, int64(value))
-}
-
-func (enc *cliEncoder) AddInt16(key string, value int16) {
-	enc.addKey(key)
-	enc.AddInt64(key, int64(value))
-}
-
-func (enc *cliEncoder) AddInt8(key string, value int8) {
-	enc.addKey(key)
-	enc.AddInt64(key, int64(value))
-}
-
 func (enc *cliEncoder) AddString(key, value string) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendString(value)
 }
 
 func (enc *cliEncoder) AddTime(key string, value time.Time) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendTime(value)
 }
 
-func (enc *cliEncoder) AddUint(key string, value uint) {
-	enc.addKey(key)
-	enc.AddUint64(key, uint64(value))
-}
+func (enc *cliEncoder) AddUint(key string, value uint)       { enc.AddUint64(key, uint64(value)) }
+func (enc *cliEncoder) AddUint32(key string, value uint32)   { enc.AddUint64(key, uint64(value)) }
+func (enc *cliEncoder) AddUint16(key string, value uint16)   { enc.AddUint64(key, uint64(value)) }
+func (enc *cliEncoder) AddUint8(key string, value uint8)     { enc.AddUint64(key, uint64(value)) }
+func (enc *cliEncoder) AddUintptr(key string, value uintptr) { enc.AddUint64(key, uint64(value)) }
 
 func (enc *cliEncoder) AddUint64(key string, value uint64) {
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return
+	}
 	enc.addKey(key)
 	enc.AppendUint64(value)
 }
 
-func (enc *cliEncoder) AddUint32(key string, value uint32) {
-	enc.addKey(key)
-	enc.AddUint64(key, uint64(value))
-}
-
-func (enc *cliEncoder) AddUint16(key string, value uint16) {
-	enc.addKey(key)
-	enc.AddUint64(key, uint64(value))
-}
-
-func (enc *cliEncoder) AddUint8(key string, value uint8) {
-	enc.addKey(key)
-	enc.AddUint64(key, uint64(value))
-}
-
-func (enc *cliEncoder) AddUintptr(key string, value uintptr) {
-	enc.addKey(key)
-	enc.AddUint64(key, uint64(value))
-}
-
 // AddReflected uses reflection to serialize arbitrary objects, so it can be
 // slow and allocation-heavy.
 func (enc *cliEncoder) AddReflected(key string, value interface{}) error {
-	valueBytes, err := enc.encodeReflected(value)
+	if enc.shouldRedact(key) {
+		enc.addRedacted(key, value)
+		return nil
+	}
+	if value == nil {
+		enc.addKey(key)
+		enc.appendNull()
+		return nil
+	}
+	decoded, err := enc.decodeReflected(value)
 	if err != nil {
 		return err
 	}
-	enc.addKey(key)
-	_, err = enc.buf.Write(valueBytes)
-	return err
+	return addJSON(enc, key, decoded)
 }
 
 // OpenNamespace opens an isolated namespace where all subsequent fields will
 // be added. Applications can use namespaces to prevent key collisions when
 // injecting loggers into sub-components or third-party libraries.
+//
+// Namespaces are either written as a nested block or, with
+// WithDottedNamespaces, as a prefix of the keys of their fields.
 func (enc *cliEncoder) OpenNamespace(key string) {
-	enc.addKey(key)
-	enc.buf.AppendByte('{')
 	enc.openNamespaces++
+	if enc.opts.dottedNamespaces {
+		enc.namespaces = append(enc.namespaces, key)
+		return
+	}
+	enc.addKey(key)
+	enc.paint(enc.colors().punctuation, "{")
+	enc.first = true
 }
 
 // The following implements the PrimitiveArrayEncoder and ArrayEncoder interfaces.
 func (enc *cliEncoder) AppendBool(value bool) {
 	enc.addElementSeparator()
+	style := enc.style(enc.colors().boolean)
+	enc.on(style)
 	enc.buf.AppendBool(value)
+	enc.off(style)
 }
 
 func (enc *cliEncoder) AppendByteString(value []byte) {
+	if p := enc.opts.redaction; p != nil && len(p.Values) > 0 {
+		value = []byte(p.redactString(string(value)))
+	}
 	enc.addElementSeparator()
+	value, elided := enc.truncateBytes(value)
+	style := enc.style(enc.colors().str)
+	enc.on(style)
 	enc.buf.AppendByte('"')
-	enc.safeAddByteString(value)
-	enc.buf.AppendByte('"')
+	safeAppendByteString(enc.buf, value)
+	enc.off(style)
+	enc.appendElision(elided, "bytes")
+	enc.paint(style, `"`)
 }
 
 func (enc *cliEncoder) AppendComplex128(value complex128) { enc.appendComplex(complex128(value), 64) }
@@ -291,7 +392,10 @@ func (enc *cliEncoder) AppendInt(value int)               { enc.AppendInt64(int6
 
 func (enc *cliEncoder) AppendInt64(value int64) {
 	enc.addElementSeparator()
+	style := enc.style(enc.colors().number)
+	enc.on(style)
 	enc.buf.AppendInt(value)
+	enc.off(style)
 }
 
 func (enc *cliEncoder) AppendInt32(value int32) { enc.AppendInt64(int64(value)) }
@@ -299,20 +403,38 @@ func (enc *cliEncoder) AppendInt16(value int16) { enc.AppendInt64(int64(value))
 func (enc *cliEncoder) AppendInt8(value int8)   { enc.AppendInt64(int64(value)) }
 
 func (enc *cliEncoder) AppendString(value string) {
+	if p := enc.opts.redaction; p != nil {
+		value = p.redactString(value)
+	}
 	enc.addElementSeparator()
-	enc.buf.AppendString(colorize('"', color.FgGreen))
-	enc.buf.AppendString(colorize(value, color.FgGreen))
-	enc.buf.AppendString(colorize('"', color.FgGreen))
+	value, elided := enc.truncate(value)
+	style := enc.style(enc.colors().str)
+	enc.on(style)
+	enc.buf.AppendByte('"')
+	enc.buf.AppendString(value)
+	enc.off(style)
+	enc.appendElision(elided, "bytes")
+	enc.paint(style, `"`)
 }
 
 func (enc *cliEncoder) AppendUint(value uint)       { enc.AppendUint64(uint64(value)) }
-func (enc *cliEncoder) AppendUint64(value uint64)   { enc.AppendUint64(uint64(value)) }
 func (enc *cliEncoder) AppendUint32(value uint32)   { enc.AppendUint64(uint64(value)) }
 func (enc *cliEncoder) AppendUint16(value uint16)   { enc.AppendUint64(uint64(value)) }
 func (enc *cliEncoder) AppendUint8(value uint8)     { enc.AppendUint64(uint64(value)) }
 func (enc *cliEncoder) AppendUintptr(value uintptr) { enc.AppendUint64(uint64(value)) }
 
+func (enc *cliEncoder) AppendUint64(value uint64) {
+	enc.addElementSeparator()
+	style := enc.style(enc.colors().number)
+	enc.on(style)
+	enc.buf.AppendUint(value)
+	enc.off(style)
+}
+
 func (enc *cliEncoder) AppendDuration(value time.Duration) {
+	old := enc.valueStyle
+	enc.valueStyle = &enc.colors().duration
+
 	cur := enc.buf.Len()
 	if e := enc.EncodeDuration; e != nil {
 		e(value, enc)
@@ -322,9 +444,14 @@ func (enc *cliEncoder) AppendDuration(value time.Duration) {
 		// JSON valid.
 		enc.AppendInt64(int64(value))
 	}
+
+	enc.valueStyle = old
 }
 
 func (enc *cliEncoder) AppendTime(value time.Time) {
+	old := enc.valueStyle
+	enc.valueStyle = &enc.colors().time
+
 	cur := enc.buf.Len()
 	if e := enc.EncodeTime; e != nil {
 		e(value, enc)
@@ -334,52 +461,81 @@ func (enc *cliEncoder) AppendTime(value time.Time) {
 		// output JSON valueid.
 		enc.AppendInt64(value.UnixNano())
 	}
+
+	enc.valueStyle = old
 }
 
 func (enc *cliEncoder) AppendArray(value zapcore.ArrayMarshaler) error {
 	enc.addElementSeparator()
-	enc.buf.AppendString(colorize('[', color.FgWhite, color.Bold))
-	err := value.MarshalLogArray(enc)
-	enc.buf.AppendString(colorize(']', color.FgWhite, color.Bold))
+	if enc.atMaxDepth() {
+		enc.paint(enc.colors().elision, "[…]")
+		return nil
+	}
+	enc.paint(enc.colors().punctuation, "[")
+	enc.first = true
+	enc.depth++
+	var err error
+	if l := enc.limits(); l != nil && l.MaxArrayElements > 0 {
+		arr := &limitedArrayEncoder{cliEncoder: enc, limit: l.MaxArrayElements}
+		err = value.MarshalLogArray(arr)
+		if arr.elided() > 0 {
+			enc.addElementSeparator()
+			enc.appendElision(arr.elided(), "elements")
+		}
+	} else {
+		err = value.MarshalLogArray(enc)
+	}
+	enc.depth--
+	enc.first = false
+	enc.paint(enc.colors().punctuation, "]")
 	return err
 }
 
 func (enc *cliEncoder) AppendObject(value zapcore.ObjectMarshaler) error {
-	// Close ONLY new openNamespaces that are created during
-	// AppendObject().
-	old := enc.openNamespaces
-	enc.openNamespaces = 0
 	enc.addElementSeparator()
-	enc.buf.AppendString(colorize('{', color.FgWhite, color.Bold))
+	if enc.atMaxDepth() {
+		enc.paint(enc.colors().elision, "{…}")
+		return nil
+	}
+	// Close ONLY new openNamespaces that are created during
+	// AppendObject(). Keys of the object aren't part of the outer
+	// namespaces either.
+	old, oldNamespaces := enc.openNamespaces, enc.namespaces
+	enc.openNamespaces, enc.namespaces = 0, nil
+	enc.paint(enc.colors().punctuation, "{")
+	enc.first = true
+	enc.depth++
 	err := value.MarshalLogObject(enc)
-	enc.buf.AppendString(colorize('}', color.FgWhite, color.Bold))
+	enc.depth--
 	enc.closeOpenNamespaces()
-	enc.openNamespaces = old
+	enc.first = false
+	enc.paint(enc.colors().punctuation, "}")
+	enc.openNamespaces, enc.namespaces = old, oldNamespaces
 	return err
 }
 
 func (enc *cliEncoder) AppendReflected(value interface{}) error {
-	valueBytes, err := enc.encodeReflected(value)
+	if value == nil {
+		enc.appendNull()
+		return nil
+	}
+	decoded, err := enc.decodeReflected(value)
 	if err != nil {
 		return err
 	}
-	enc.addElementSeparator()
-	_, err = enc.buf.Write(valueBytes)
-	return err
+	return appendJSON(enc, decoded)
 }
 
-// Only invoke the standard JSON encoder if there is actually something to
-// encode; otherwise write JSON null literal directly.
-func (enc *cliEncoder) encodeReflected(obj interface{}) ([]byte, error) {
-	if obj == nil {
-		return nullLiteralBytes, nil
-	}
+// decodeReflected marshals obj with the reflected encoder and decodes the
+// result again so it can be written field by field, the same way as any other
+// object or array. That way reflected values are colored, redacted and
+// limited too.
+func (enc *cliEncoder) decodeReflected(obj interface{}) (interface{}, error) {
 	enc.resetReflectBuf()
 	if err := enc.reflectEnc.Encode(obj); err != nil {
 		return nil, err
 	}
-	enc.reflectBuf.TrimNewline()
-	return enc.reflectBuf.Bytes(), nil
+	return DecodeJSON(enc.reflectBuf.Bytes())
 }
 
 func (enc *cliEncoder) resetReflectBuf() {
@@ -398,9 +554,8 @@ func (enc *cliEncoder) appendComplex(val complex128, precision int) {
 	enc.addElementSeparator()
 	// Cast to a platform-independent, fixed-size type.
 	r, i := float64(real(val)), float64(imag(val))
-	enc.buf.AppendByte('"')
-	// Because we're always in a quoted string, we can use strconv without
-	// special-casing NaN and +/-Inf.
+	style := enc.style(enc.colors().number)
+	enc.on(style)
 	enc.buf.AppendFloat(r, precision)
 	// If imaginary part is less than 0, minus (-) sign is added by default
 	// by AppendFloat.
@@ -409,142 +564,258 @@ func (enc *cliEncoder) appendComplex(val complex128, precision int) {
 	}
 	enc.buf.AppendFloat(i, precision)
 	enc.buf.AppendByte('i')
-	enc.buf.AppendByte('"')
+	enc.off(style)
 }
 
 func (enc *cliEncoder) appendFloat(val float64, bitSize int) {
 	enc.addElementSeparator()
+	style := enc.style(enc.colors().number)
+	enc.on(style)
 	switch {
 	case math.IsNaN(val):
-		enc.buf.AppendString(`"NaN"`)
+		enc.buf.AppendString("NaN")
 	case math.IsInf(val, 1):
-		enc.buf.AppendString(`"+Inf"`)
+		enc.buf.AppendString("+Inf")
 	case math.IsInf(val, -1):
-		enc.buf.AppendString(`"-Inf"`)
+		enc.buf.AppendString("-Inf")
 	default:
 		enc.buf.AppendFloat(val, bitSize)
 	}
+	enc.off(style)
+}
+
+func (enc *cliEncoder) appendNull() {
+	enc.addElementSeparator()
+	enc.on(enc.colors().null)
+	enc.buf.AppendBytes(nullLiteralBytes)
+	enc.off(enc.colors().null)
+}
+
+// appendBinary writes a preview of value like hexdump -C does: its bytes in
+// hex followed by their printable form.
+func (enc *cliEncoder) appendBinary(value []byte) {
+	enc.addElementSeparator()
+	value, elided := enc.truncateBytes(value)
+
+	enc.on(enc.colors().bytes)
+	for i, b := range value {
+		if i > 0 {
+			enc.buf.AppendByte(' ')
+		}
+		enc.buf.AppendByte(hex[b>>4])
+		enc.buf.AppendByte(hex[b&0xF])
+	}
+	if len(value) > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	enc.buf.AppendByte('|')
+	for _, b := range value {
+		if b < 0x20 || b > 0x7e {
+			b = '.'
+		}
+		enc.buf.AppendByte(b)
+	}
+	enc.buf.AppendByte('|')
+	enc.off(enc.colors().bytes)
+
+	enc.appendElision(elided, "bytes")
 }
 
 func (enc *cliEncoder) clone() *cliEncoder {
 	clone := getCLIEncoder()
 	clone.EncoderConfig = enc.EncoderConfig
+	clone.opts = enc.opts
 	clone.buf = bufPool.Get()
+	clone.openNamespaces = enc.openNamespaces
+	clone.namespaces = append([]string(nil), enc.namespaces...)
 	return clone
 }
 
+// colors returns the escape sequences of the encoder's theme.
+func (enc *cliEncoder) colors() *palette {
+	return enc.opts.palette
+}
+
+// style returns the style to write a primitive value with. It's usually s,
+// unless a duration or time is being written.
+func (enc *cliEncoder) style(s sgr) sgr {
+	if enc.valueStyle != nil {
+		return *enc.valueStyle
+	}
+	return s
+}
+
+// on starts writing in the given style.
+func (enc *cliEncoder) on(style sgr) {
+	if !color.NoColor {
+		enc.buf.AppendString(style.on)
+	}
+}
+
+// off stops writing in the given style.
+func (enc *cliEncoder) off(style sgr) {
+	if !color.NoColor {
+		enc.buf.AppendString(style.off)
+	}
+}
+
+// paint writes s to the buffer in the given style.
+func (enc *cliEncoder) paint(style sgr, s string) {
+	enc.on(style)
+	enc.buf.AppendString(s)
+	enc.off(style)
+}
+
 func (enc *cliEncoder) encodeTimestamp(timestamp time.Time) {
-	enc.buf.WriteString(color.New(color.FgWhite).Sprintf("[%s]", timestamp.Format(timeFormat)))
-	enc.buf.WriteString(" ")
+	enc.on(enc.colors().timestamp)
+	enc.buf.AppendByte('[')
+	enc.buf.AppendTime(timestamp, enc.opts.timeLayout)
+	enc.buf.AppendByte(']')
+	enc.off(enc.colors().timestamp)
+	enc.buf.AppendByte(' ')
 }
 
 func (enc *cliEncoder) encodeLevel(level zapcore.Level) {
+	style := enc.colors().level(level)
+	enc.on(style)
+	enc.buf.AppendString(level.CapitalString())
 	if level == zapcore.InfoLevel {
-		enc.buf.WriteString(color.New(levelColor[level]).Sprint(level.CapitalString() + " "))
-	} else {
-		enc.buf.WriteString(color.New(levelColor[level]).Sprint(level.CapitalString()))
+		enc.buf.AppendByte(' ')
 	}
-	enc.buf.WriteString(" ")
+	enc.off(style)
+	enc.buf.AppendByte(' ')
 }
 
 func (enc *cliEncoder) encodeLoggerName(logger string) {
-	enc.buf.WriteString(color.New(color.FgHiBlack).Sprint(logger))
-	enc.buf.WriteString(" ")
+	enc.paint(enc.colors().loggerName, logger)
+	enc.buf.AppendByte(' ')
 }
 
 func (enc *cliEncoder) encodeCaller(caller zapcore.EntryCaller) {
-	enc.buf.WriteString(color.New(color.FgHiBlack).Sprintf("(%s)", caller.TrimmedPath()))
-	enc.buf.WriteString(" ")
+	enc.on(enc.colors().caller)
+	enc.buf.AppendByte('(')
+	if enc.hyperlinks(caller) {
+		enc.opts.hyperlink.appendLinkStart(enc.buf, caller)
+		appendTrimmedPath(enc.buf, caller)
+		appendLinkEnd(enc.buf)
+	} else {
+		appendTrimmedPath(enc.buf, caller)
+	}
+	enc.buf.AppendByte(')')
+	enc.off(enc.colors().caller)
+	enc.buf.AppendByte(' ')
 }
 
 func (enc *cliEncoder) encodeMessage(message string) {
-	enc.buf.WriteString(color.New(color.FgHiWhite).Sprint(message))
-	enc.buf.WriteString(" ")
+	enc.paint(enc.colors().message, message)
+	enc.buf.AppendByte(' ')
 }
 
 func (enc *cliEncoder) addKey(key string) {
 	enc.addElementSeparator()
-	enc.buf.AppendString(colorize('"', color.FgBlue, color.Bold))
-	enc.buf.AppendString(colorize(key, color.FgBlue, color.Bold))
-	enc.buf.AppendString(colorize('"', color.FgBlue, color.Bold))
-	enc.buf.AppendString(colorize(':', color.FgBlue, color.Bold))
+	enc.on(enc.colors().key)
+	enc.buf.AppendByte('"')
+	for _, ns := range enc.namespaces {
+		enc.buf.AppendString(ns)
+		enc.buf.AppendByte('.')
+	}
+	enc.buf.AppendString(key)
+	enc.buf.AppendString(`":`)
+	enc.off(enc.colors().key)
 	enc.buf.AppendByte(' ')
+	enc.first = true
+}
+
+// shouldRedact reports whether the value of key has to be redacted.
+func (enc *cliEncoder) shouldRedact(key string) bool {
+	return enc.opts.redaction != nil && enc.opts.redaction.matchKey(key)
+}
+
+// addRedacted adds key with the redacted form of value in place of value.
+func (enc *cliEncoder) addRedacted(key string, value interface{}) {
+	enc.addKey(key)
+	enc.addElementSeparator()
+	enc.on(enc.colors().str)
+	enc.buf.AppendByte('"')
+	enc.buf.AppendString(enc.opts.redaction.redactValue(value))
+	enc.buf.AppendByte('"')
+	enc.off(enc.colors().str)
 }
 
 func (enc *cliEncoder) addElementSeparator() {
-	last := enc.buf.Len() - 1
-	if last < 0 {
-		return
-	}
-	switch enc.buf.Bytes()[last] {
-	case '{', '[', ':', ',', ' ':
+	if enc.first || enc.buf.Len() == 0 {
+		enc.first = false
 		return
-	default:
-		enc.buf.AppendString(colorize(',', color.FgWhite, color.Bold))
-		enc.buf.AppendByte(' ')
 	}
+	enc.paint(enc.colors().punctuation, ",")
+	enc.buf.AppendByte(' ')
 }
 
 func (enc *cliEncoder) closeOpenNamespaces() {
+	if enc.opts.dottedNamespaces {
+		enc.namespaces = enc.namespaces[:len(enc.namespaces)-enc.openNamespaces]
+		enc.openNamespaces = 0
+		return
+	}
 	for i := 0; i < enc.openNamespaces; i++ {
-		enc.buf.AppendString(colorize('}', color.FgWhite, color.Bold))
+		enc.paint(enc.colors().punctuation, "}")
 	}
 	enc.openNamespaces = 0
 }
 
-// safeAddByteString is no-alloc equivalent of safeAddString(string(s)) for s
-// []byte.
-func (enc *cliEncoder) safeAddByteString(s []byte) {
+// safeAppendByteString is no-alloc equivalent of safeAddString(string(s)) for
+// s []byte.
+func safeAppendByteString(buf *buffer.Buffer, s []byte) {
 	for i := 0; i < len(s); {
-		if enc.tryAddRuneSelf(s[i]) {
+		if tryAppendRuneSelf(buf, s[i]) {
 			i++
 			continue
 		}
 		r, size := utf8.DecodeRune(s[i:])
-		if enc.tryAddRuneError(r, size) {
+		if tryAppendRuneError(buf, r, size) {
 			i++
 			continue
 		}
-		enc.buf.Write(s[i : i+size])
+		buf.Write(s[i : i+size])
 		i += size
 	}
 }
 
-// tryAddRuneSelf appends b if it is valid UTF-8 character represented in a
+// tryAppendRuneSelf appends b if it is valid UTF-8 character represented in a
 // single byte.
-func (enc *cliEncoder) tryAddRuneSelf(b byte) bool {
+func tryAppendRuneSelf(buf *buffer.Buffer, b byte) bool {
 	if b >= utf8.RuneSelf {
 		return false
 	}
 	if 0x20 <= b && b != '\\' && b != '"' {
-		enc.buf.AppendByte(b)
+		buf.AppendByte(b)
 		return true
 	}
 	switch b {
 	case '\\', '"':
-		enc.buf.AppendByte('\\')
-		enc.buf.AppendByte(b)
+		buf.AppendByte('\\')
+		buf.AppendByte(b)
 	case '\n':
-		enc.buf.AppendByte('\\')
-		enc.buf.AppendByte('n')
+		buf.AppendByte('\\')
+		buf.AppendByte('n')
 	case '\r':
-		enc.buf.AppendByte('\\')
-		enc.buf.AppendByte('r')
+		buf.AppendByte('\\')
+		buf.AppendByte('r')
 	case '\t':
-		enc.buf.AppendByte('\\')
-		enc.buf.AppendByte('t')
+		buf.AppendByte('\\')
+		buf.AppendByte('t')
 	default:
 		// Encode bytes < 0x20, except for the escape sequences above.
-		enc.buf.AppendString(`\u00`)
-		enc.buf.AppendByte(hex[b>>4])
-		enc.buf.AppendByte(hex[b&0xF])
+		buf.AppendString(`\u00`)
+		buf.AppendByte(hex[b>>4])
+		buf.AppendByte(hex[b&0xF])
 	}
 	return true
 }
 
-func (enc *cliEncoder) tryAddRuneError(r rune, size int) bool {
+func tryAppendRuneError(buf *buffer.Buffer, r rune, size int) bool {
 	if r == utf8.RuneError && size == 1 {
-		enc.buf.AppendString(`\ufffd`)
+		buf.AppendString(`\ufffd`)
 		return true
 	}
 	return false
@@ -559,8 +830,16 @@ func putCLIEncoder(enc *cliEncoder) {
 		enc.reflectBuf.Free()
 	}
 	enc.EncoderConfig = nil
+	enc.opts = nil
 	enc.buf = nil
 	enc.openNamespaces = 0
+	enc.first = false
+	enc.namespaces = nil
+	enc.valueStyle = nil
+	enc.depth = 0
+	enc.unlimited = false
+	enc.diffs = nil
+	enc.hints = nil
 	enc.reflectBuf = nil
 	enc.reflectEnc = nil
 	cliPool.Put(enc)
@@ -573,13 +852,16 @@ func defaultReflectedEncoder(w io.Writer) zapcore.ReflectedEncoder {
 	return enc
 }
 
-func colorize(arg any, attributes ...color.Attribute) string {
-	switch x := arg.(type) {
-	case byte:
-		return color.New(attributes...).Sprint(string(x))
-	case rune:
-		return color.New(attributes...).Sprint(string(x))
-	default:
-		return color.New(attributes...).Sprint(arg)
+// appendTrimmedPath is an allocation-free version of caller.TrimmedPath.
+func appendTrimmedPath(buf *buffer.Buffer, caller zapcore.EntryCaller) {
+	// Find the last separator, then the penultimate one.
+	file := caller.File
+	if idx := strings.LastIndexByte(file, '/'); idx >= 0 {
+		if idx = strings.LastIndexByte(file[:idx], '/'); idx >= 0 {
+			file = file[idx+1:]
+		}
 	}
+	buf.AppendString(file)
+	buf.AppendByte(':')
+	buf.AppendInt(int64(caller.Line))
 }
@@ -23,47 +23,11 @@ func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m)
 }
 
-func TestPrettyOutput(t *testing.T) {
-	testcases := []struct {
-		name  string
-		entry zapcore.Entry
-		want  string
-	}{
-		{
-			name: "info with caller",
-			entry: zapcore.Entry{
-				Level:      zapcore.InfoLevel,
-				Time:       epoch,
-				LoggerName: "main",
-				Message:    "hello world",
-				Caller: zapcore.EntryCaller{
-					Defined:  true,
-					File:     "foo.go",
-					Line:     42,
-					Function: "foo.Bar",
-				},
-				Stack: "foo",
-			},
-			want: fmt.Sprintf("\x1b[37m[%s]\x1b[0m \x1b[32mINFO \x1b[0m \x1b[90mmain\x1b[0m \x1b[90m(foo.go:42)\x1b[0m \x1b[97mhello world\x1b[0m \n", epoch.Format(timeFormat)),
-		},
-	}
-
-	for _, tc := range testcases {
-		encoder := NewCLIEncoder(EncoderTestEncoderConfig())
-
-		t.Run(tc.name, func(t *testing.T) {
-			out, err := encoder.EncodeEntry(tc.entry, nil)
-			assert.NoError(t, err)
-			assert.Equal(t, tc.want, out.String(), "Unexpected output")
-		})
-	}
-}
-
 func TestFuzzLog(t *testing.T) {
 	atom := zap.NewAtomicLevel()
 	cfg := zap.NewProductionEncoderConfig()
 
-	Register(cfg)
+	assert.NoError(t, Register("cli"))
 
 	cliEncoder := NewCLIEncoder(cfg)
 	jsonEncoder := zapcore.NewJSONEncoder(cfg)
@@ -108,3 +72,77 @@ func TestFuzzLog(t *testing.T) {
 		logger.Info(corpus[i])
 	}
 }
+
+func TestFieldOutput(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	testcases := []struct {
+		name    string
+		options []Option
+		context []zapcore.Field
+		fields  []zapcore.Field
+		want    string
+	}{
+		{
+			name:   "primitives",
+			fields: []zapcore.Field{zap.Int("int", 1), zap.Uint("uint", 2), zap.Bool("bool", true), zap.Float64("float", 1.5), zap.Complex128("complex", 1-2i)},
+			want:   `{ "int": 1, "uint": 2, "bool": true, "float": 1.5, "complex": 1-2i }`,
+		},
+		{
+			name:   "array and object",
+			fields: []zapcore.Field{zap.Strings("strings", []string{"a", "b"}), zap.Reflect("object", map[string]int{"a": 1})},
+			want:   `{ "strings": ["a", "b"], "object": {"a": 1} }`,
+		},
+		{
+			name:   "binary",
+			fields: []zapcore.Field{zap.Binary("binary", []byte("hi\x00"))},
+			want:   `{ "binary": 68 69 00 |hi.| }`,
+		},
+		{
+			name:   "null",
+			fields: []zapcore.Field{zap.Reflect("null", nil)},
+			want:   `{ "null": null }`,
+		},
+		{
+			name:   "namespace",
+			fields: []zapcore.Field{zap.String("a", "b"), zap.Namespace("http"), zap.String("method", "GET"), zap.Int("status", 200)},
+			want:   `{ "a": "b", "http": {"method": "GET", "status": 200} }`,
+		},
+		{
+			name:    "dotted namespace",
+			options: []Option{WithDottedNamespaces()},
+			fields:  []zapcore.Field{zap.String("a", "b"), zap.Namespace("http"), zap.String("method", "GET"), zap.Int("status", 200)},
+			want:    `{ "a": "b", "http.method": "GET", "http.status": 200 }`,
+		},
+		{
+			name:    "context",
+			context: []zapcore.Field{zap.String("a", "b"), zap.Namespace("http")},
+			fields:  []zapcore.Field{zap.String("method", "GET")},
+			want:    `{ "a": "b", "http": {"method": "GET"} }`,
+		},
+		{
+			name:    "dotted context",
+			options: []Option{WithDottedNamespaces()},
+			context: []zapcore.Field{zap.String("a", "b"), zap.Namespace("http")},
+			fields:  []zapcore.Field{zap.String("method", "GET")},
+			want:    `{ "a": "b", "http.method": "GET" }`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoder := NewCLIEncoder(EncoderTestEncoderConfig(), tc.options...)
+			if len(tc.context) > 0 {
+				encoder = encoder.Clone()
+				for _, field := range tc.context {
+					field.AddTo(encoder)
+				}
+			}
+
+			out, err := encoder.EncodeEntry(zapcore.Entry{Time: epoch}, tc.fields)
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("[%s] INFO   %s\n", epoch.Format(timeFormat), tc.want), out.String())
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package zapprettytest compares log output written with zappretty against
+// golden files.
+//
+// Golden files live in the testdata directory of the package under test. Each
+// test has two of them: <test name>.ansi.golden with the output exactly as it
+// was written, color escape sequences included, and <test name>.golden with
+// the escape sequences stripped so it can be read and reviewed. Run the tests
+// with -update (or tools/update-testdata) to rewrite them. Like the other
+// golden file tests of this repository, the package under test defines the
+// flag itself:
+//
+//	var update = flag.Bool("update", false, "update golden files in testdata")
+package zapprettytest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rdeusser/x/zappretty"
+)
+
+// escapes matches the ANSI escape sequences written by zappretty: SGR
+// sequences for colors and OSC 8 sequences for hyperlinks.
+var escapes = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]|\x1b\]8;[^\x1b\a]*(?:\x1b\\|\a)`)
+
+// Epoch is the time of every entry written with a Snapshot's logger.
+var Epoch = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Strip removes color and hyperlink escape sequences from b.
+func Strip(b []byte) []byte {
+	return escapes.ReplaceAll(b, nil)
+}
+
+// AssertGolden compares got with the golden files of the running test. With
+// -update, the golden files are written instead.
+func AssertGolden(t testing.TB, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name()))
+	ansiPath := path + ".ansi.golden"
+	plainPath := path + ".golden"
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(ansiPath, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(plainPath, Strip(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	wantANSI, err := os.ReadFile(ansiPath)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+
+	wantPlain, err := os.ReadFile(plainPath)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+
+	// Compare the readable output first so text differences are easy to
+	// spot, then make sure the colors match too.
+	if assert.Equal(t, string(wantPlain), string(Strip(got)), "output differs from %s", plainPath) {
+		assert.Equal(t, string(wantANSI), string(got), "colors differ from %s", ansiPath)
+	}
+}
+
+// updating reports whether the test binary was run with -update. The flag is
+// looked up rather than defined here so the packages that define it can import
+// this one.
+func updating() bool {
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+
+	update, _ := strconv.ParseBool(f.Value.String())
+	return update
+}
+
+// Snapshot records the output of a logger that writes entries with zappretty
+// so it can be compared to golden files.
+//
+// Entries are always written at Epoch and with colors enabled, so the output
+// only depends on what was logged.
+type Snapshot struct {
+	t      testing.TB
+	buf    bytes.Buffer
+	logger *zap.Logger
+}
+
+// NewSnapshot returns a Snapshot whose logger writes entries at every level
+// with an encoder configured with opts.
+func NewSnapshot(t testing.TB, opts ...zappretty.Option) *Snapshot {
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	s := &Snapshot{t: t}
+
+	encoder := zappretty.NewCLIEncoder(zappretty.EncoderTestEncoderConfig(), opts...)
+	core := zapcore.NewCore(encoder, zapcore.AddSync(&s.buf), zapcore.DebugLevel)
+	s.logger = zap.New(core, zap.WithClock(clock{}))
+
+	return s
+}
+
+// Logger returns the logger whose output is recorded.
+func (s *Snapshot) Logger() *zap.Logger {
+	return s.logger
+}
+
+// Bytes returns the output recorded so far.
+func (s *Snapshot) Bytes() []byte {
+	return s.buf.Bytes()
+}
+
+// Assert compares the output recorded so far with the golden files of the
+// running test.
+func (s *Snapshot) Assert() {
+	s.t.Helper()
+	AssertGolden(s.t, s.buf.Bytes())
+}
+
+// clock always returns Epoch.
+type clock struct{}
+
+func (clock) Now() time.Time { return Epoch }
+
+func (clock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
@@ -0,0 +1,31 @@
+package zapprettytest
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Defining -update like the packages using zapprettytest do mustn't conflict
+// with zapprettytest.
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestUpdating(t *testing.T) {
+	assert.Equal(t, *update, updating())
+
+	f := flag.Lookup("update")
+	old := f.Value.String()
+	t.Cleanup(func() { f.Value.Set(old) })
+
+	f.Value.Set("true")
+	assert.True(t, updating())
+
+	f.Value.Set("false")
+	assert.False(t, updating())
+}
+
+func TestStrip(t *testing.T) {
+	got := Strip([]byte("\x1b[1;31mERROR\x1b[0m \x1b]8;;file:///foo.go\x1b\\foo.go:42\x1b]8;;\x1b\\"))
+	assert.Equal(t, "ERROR foo.go:42", string(got))
+}